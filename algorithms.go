@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// algorithms maps the names accepted on the command line to the extraction options they stand for
+// each builder gets the number of keywords to return
+var algorithms = map[string]func(numKeywords int) ExtractOptions{
+	// plain term frequency, the same as getStringKeywords
+	"tf": func(numKeywords int) ExtractOptions {
+		return ExtractOptions{NumKeywords: numKeywords}
+	},
+	// term frequency favouring terms introduced early on
+	"tf-position": func(numKeywords int) ExtractOptions {
+		return ExtractOptions{
+			NumKeywords: numKeywords,
+			Position:    &PositionWeighting{FirstOccurrence: exponentialDecay(1), FirstParagraphBoost: 1.5},
+		}
+	},
+	// term frequency with collocations significant at p < 0.001 promoted to single terms
+	"collocation": func(numKeywords int) ExtractOptions {
+		return ExtractOptions{
+			NumKeywords:  numKeywords,
+			Collocations: &CollocationOptions{MaxN: 3, MinCount: 2, Measure: "llr", Threshold: 10.83},
+		}
+	},
+	// collocations restricted to noun phrases
+	"noun-phrase": func(numKeywords int) ExtractOptions {
+		pattern, _ := compilePOSPattern("(ADJ)*(NOUN)+")
+		return ExtractOptions{
+			NumKeywords:  numKeywords,
+			Collocations: &CollocationOptions{MaxN: 3, MinCount: 2, Measure: "llr", Threshold: 10.83},
+			POSPattern:   pattern,
+		}
+	},
+}
+
+// returns the extraction options for a named algorithm
+func newAlgorithmOptions(name string, numKeywords int) (ExtractOptions, error) {
+	build, ok := algorithms[name]
+	if !ok {
+		return ExtractOptions{}, errors.New("unknown algorithm " + name + ", expected one of: " + strings.Join(algorithmNames(), ", "))
+	}
+	return build(numKeywords), nil
+}
+
+// returns the names of every algorithm in alphabetical order
+func algorithmNames() []string {
+	names := make([]string, 0, len(algorithms))
+	for name := range algorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
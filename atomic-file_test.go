@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+/*
+This file tests for:
+- replacing a file's contents atomically
+- leaving no temporary files behind
+*/
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.json")
+	os.WriteFile(path, []byte("old"), 0644)
+
+	if err := writeFileAtomic(path, []byte("new"), 0600); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "new" {
+		t.Errorf("Expected new, got %q", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only the written file, got %d entries", len(entries))
+	}
+
+	if err := writeFileAtomic(filepath.Join(dir, "missing", "index.json"), []byte("new"), 0644); err == nil {
+		t.Error("Expected error writing into a missing directory, got nil")
+	}
+}
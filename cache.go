@@ -0,0 +1,217 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// KeywordCache remembers the keywords extracted from text so unchanged text is not extracted again
+// results are keyed by a hash of the text and a hash of the config used, kept in memory for the most
+// recently used Capacity results and, when Dir is set, saved to Dir as one JSON file each
+// the stopword, vocabulary and synonym files a config names are hashed by their contents, so editing one misses
+type KeywordCache struct {
+	Capacity int
+	Dir      string
+
+	mu      sync.Mutex
+	order   *list.List               // most recently used first
+	entries map[string]*list.Element // cache key to its element in order
+	stats   CacheStats
+}
+
+// CacheStats counts how a cache's lookups went
+// DiskHits are also counted in Hits, Evictions are results dropped from memory to stay within Capacity
+type CacheStats struct {
+	Hits      int `json:"hits"`
+	DiskHits  int `json:"disk_hits"`
+	Misses    int `json:"misses"`
+	Evictions int `json:"evictions"`
+}
+
+// an entry in a cache's recently used list
+type cacheEntry struct {
+	key      string
+	keywords []Keyword
+}
+
+// returns a cache holding up to capacity results in memory, saving them to dir as well unless it is empty
+// dir is created if it does not exist
+func newKeywordCache(capacity int, dir string) (*KeywordCache, error) {
+	if capacity < 1 {
+		return nil, errors.New("cache capacity must be at least 1")
+	}
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	return &KeywordCache{
+		Capacity: capacity,
+		Dir:      dir,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}, nil
+}
+
+// returns the key results for content extracted with config are stored under
+// the output format is left out as it does not change the keywords, the files the config names are read
+// so results are not reused after one of them is edited
+func keywordCacheKey(content string, config Config) (string, error) {
+	config.Format = ""
+	encoded, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	configHash := sha256.New()
+	configHash.Write(encoded)
+	for _, path := range config.referencedFiles() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		fileSum := sha256.Sum256(data)
+		configHash.Write(fileSum[:])
+	}
+	contentSum := sha256.Sum256([]byte(content))
+	configSum := configHash.Sum(nil)
+	return hex.EncodeToString(contentSum[:]) + "-" + hex.EncodeToString(configSum[:8]), nil
+}
+
+// returns the files options reads for a config, in a fixed order
+// the default stopwords file is read when the config lists none of its own
+func (c Config) referencedFiles() []string {
+	var paths []string
+	if len(c.Filters.Stopwords) == 0 {
+		paths = append(paths, defaultStopwordsPath)
+	}
+	paths = append(paths, c.Filters.Stopwords...)
+	if c.Vocabulary.Path != "" {
+		paths = append(paths, c.Vocabulary.Path)
+	}
+	return append(paths, c.Synonyms.Files...)
+}
+
+// Extract returns the keywords of content extracted with config, from the cache when it has them
+func (cache *KeywordCache) Extract(content string, config Config) ([]Keyword, error) {
+	key, err := keywordCacheKey(content, config)
+	if err != nil {
+		return nil, err
+	}
+	if keywords, ok := cache.get(key); ok {
+		return keywords, nil
+	}
+
+	opts, err := config.options()
+	if err != nil {
+		return nil, err
+	}
+	keywords, err := extractKeywords(content, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := cache.put(key, keywords); err != nil {
+		return nil, err
+	}
+	return append([]Keyword(nil), keywords...), nil
+}
+
+// StringKeywords is getStringKeywords going through the cache
+func (cache *KeywordCache) StringKeywords(content string, numKeywords int) []string {
+	config := defaultConfig()
+	config.NumKeywords = numKeywords
+	keywords, err := cache.Extract(content, config)
+	if err != nil {
+		print("Error extracting keywords:", err)
+		return nil
+	}
+	return keywordTerms(keywords)
+}
+
+// FileKeywords is getFileKeywords going through the cache
+func (cache *KeywordCache) FileKeywords(filePath string, numKeywords int) []string {
+	content, err := LoadFileContent(filePath)
+	if err != nil {
+		print("Error loading file content:", err)
+		return nil
+	}
+	return cache.StringKeywords(content, numKeywords)
+}
+
+// Stats returns the counts of hits, misses and evictions so far
+func (cache *KeywordCache) Stats() CacheStats {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	return cache.stats
+}
+
+// looks a key up in memory then on disk, counting the hit or miss
+func (cache *KeywordCache) get(key string) ([]Keyword, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if element, ok := cache.entries[key]; ok {
+		cache.order.MoveToFront(element)
+		cache.stats.Hits++
+		return append([]Keyword(nil), element.Value.(*cacheEntry).keywords...), true
+	}
+
+	if cache.Dir != "" {
+		// an unreadable or corrupt file is treated as a miss and overwritten
+		if data, err := os.ReadFile(cache.path(key)); err == nil {
+			var keywords []Keyword
+			if json.Unmarshal(data, &keywords) == nil {
+				cache.remember(key, keywords)
+				cache.stats.Hits++
+				cache.stats.DiskHits++
+				return append([]Keyword(nil), keywords...), true
+			}
+		}
+	}
+
+	cache.stats.Misses++
+	return nil, false
+}
+
+// stores keywords in memory and on disk
+func (cache *KeywordCache) put(key string, keywords []Keyword) error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.remember(key, keywords)
+	if cache.Dir == "" {
+		return nil
+	}
+	data, err := json.Marshal(keywords)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(cache.path(key), data, 0644)
+}
+
+// adds keywords to the front of the recently used list, evicting the least recently used past Capacity
+// the caller holds mu
+func (cache *KeywordCache) remember(key string, keywords []Keyword) {
+	if element, ok := cache.entries[key]; ok {
+		element.Value.(*cacheEntry).keywords = keywords
+		cache.order.MoveToFront(element)
+		return
+	}
+	cache.entries[key] = cache.order.PushFront(&cacheEntry{key: key, keywords: keywords})
+	for cache.order.Len() > cache.Capacity {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*cacheEntry).key)
+		cache.stats.Evictions++
+	}
+}
+
+// the file a key is saved to in Dir
+func (cache *KeywordCache) path(key string) string {
+	return filepath.Join(cache.Dir, key+".json")
+}
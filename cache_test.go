@@ -0,0 +1,164 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+/*
+This file tests for:
+- returning cached keywords for the same text and config
+- missing when the text or config changes
+- missing when a file the config names is edited
+- evicting the least recently used result
+- reading results saved to disk by another cache
+- the cached versions of getStringKeywords and getFileKeywords
+*/
+
+// a config that does not need the default stopwords file
+func testCacheConfig(t *testing.T) Config {
+	config := defaultConfig()
+	config.Filters.Stopwords = []string{writeConfigFile(t, "stopwords.txt", "the\nand\n")}
+	return config
+}
+
+func TestKeywordCache(t *testing.T) {
+	config := testCacheConfig(t)
+	cache, err := newKeywordCache(2, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	first, err := cache.Extract("Haskell monads and Haskell types", config)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	second, _ := cache.Extract("Haskell monads and Haskell types", config)
+	if !reflect.DeepEqual(first, second) || first[0].Term != "haskell" {
+		t.Errorf("Expected the same keywords led by haskell, got %v and %v", first, second)
+	}
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Expected 1 hit and 1 miss, got %+v", stats)
+	}
+
+	// Test changing the config or text misses, but changing the format does not
+	t.Run("Keys", func(t *testing.T) {
+		fewer := config
+		fewer.NumKeywords = 1
+		keywords, _ := cache.Extract("Haskell monads and Haskell types", fewer)
+		if len(keywords) != 1 {
+			t.Errorf("Expected 1 keyword, got %d", len(keywords))
+		}
+		formatted := config
+		formatted.Format = "json"
+		cache.Extract("Haskell monads and Haskell types", formatted)
+		if stats := cache.Stats(); stats.Hits != 2 || stats.Misses != 2 {
+			t.Errorf("Expected 2 hits and 2 misses, got %+v", stats)
+		}
+	})
+
+	// Test the least recently used result is evicted
+	t.Run("Eviction", func(t *testing.T) {
+		cache.Extract("Rust ownership", config)
+		if stats := cache.Stats(); stats.Evictions != 1 {
+			t.Errorf("Expected 1 eviction, got %+v", stats)
+		}
+		cache.Extract("Haskell monads and Haskell types", config)
+		if stats := cache.Stats(); stats.Hits != 3 {
+			t.Errorf("Expected the most recently used result to be kept, got %+v", stats)
+		}
+	})
+
+	// Test callers cannot change cached results
+	t.Run("Copies", func(t *testing.T) {
+		keywords, _ := cache.Extract("Rust ownership", config)
+		keywords[0].Term = "changed"
+		again, _ := cache.Extract("Rust ownership", config)
+		if again[0].Term == "changed" {
+			t.Error("Expected the cached keywords to be unchanged")
+		}
+	})
+
+	// Test editing a stopwords file the config names misses
+	t.Run("Files", func(t *testing.T) {
+		before, err := keywordCacheKey("Haskell monads", config)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if err := os.WriteFile(config.Filters.Stopwords[0], []byte("the\nand\nhaskell\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		after, _ := keywordCacheKey("Haskell monads", config)
+		if before == after {
+			t.Errorf("Expected the key to change after editing the stopwords, got %s both times", before)
+		}
+		keywords, _ := cache.Extract("Haskell monads and Haskell types", config)
+		for _, kw := range keywords {
+			if kw.Term == "haskell" {
+				t.Error("Expected 'haskell' to be a stopword after the edit")
+			}
+		}
+
+		missing := config
+		missing.Synonyms.Files = []string{filepath.Join(t.TempDir(), "missing.txt")}
+		if _, err := keywordCacheKey("Haskell monads", missing); err == nil {
+			t.Error("Expected error for a missing synonyms file, got nil")
+		}
+	})
+
+	if _, err := newKeywordCache(0, ""); err == nil {
+		t.Error("Expected error for a capacity of 0, got nil")
+	}
+}
+
+func TestKeywordCacheDisk(t *testing.T) {
+	config := testCacheConfig(t)
+	dir := filepath.Join(t.TempDir(), "cache")
+
+	writer, err := newKeywordCache(10, dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected, _ := writer.Extract("Rust ownership and borrowing in Rust", config)
+
+	reader, _ := newKeywordCache(10, dir)
+	keywords, err := reader.Extract("Rust ownership and borrowing in Rust", config)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !reflect.DeepEqual(keywords, expected) {
+		t.Errorf("Expected %v from disk, got %v", expected, keywords)
+	}
+	if stats := reader.Stats(); stats.DiskHits != 1 || stats.Hits != 1 || stats.Misses != 0 {
+		t.Errorf("Expected 1 disk hit, got %+v", stats)
+	}
+
+	// a corrupt file is a miss
+	entries, _ := os.ReadDir(dir)
+	os.WriteFile(filepath.Join(dir, entries[0].Name()), []byte("{"), 0644)
+	other, _ := newKeywordCache(10, dir)
+	if _, err := other.Extract("Rust ownership and borrowing in Rust", config); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if stats := other.Stats(); stats.Misses != 1 {
+		t.Errorf("Expected a miss for a corrupt file, got %+v", stats)
+	}
+}
+
+func TestKeywordCacheWrappers(t *testing.T) {
+	cache, _ := newKeywordCache(10, "")
+	content := "Haskell monads. Haskell laziness. Haskell monads."
+	path := writeConfigFile(t, "doc.txt", content)
+
+	if !reflect.DeepEqual(cache.StringKeywords(content, 2), getStringKeywords(content, 2)) {
+		t.Error("Expected the same keywords as getStringKeywords")
+	}
+	if !reflect.DeepEqual(cache.FileKeywords(path, 2), getFileKeywords(path, 2)) {
+		t.Error("Expected the same keywords as getFileKeywords")
+	}
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Expected the file to hit the string's result, got %+v", stats)
+	}
+}
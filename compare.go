@@ -0,0 +1,266 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DistinctiveTerm is a term used more in one side of a comparison than the other
+// Score is signed, positive when the term is over-represented in the first side and negative for the second
+// the rates are the term's share of all counted words on each side
+type DistinctiveTerm struct {
+	Term   string  `json:"term"`
+	Score  float64 `json:"score"`
+	CountA int     `json:"count_a"`
+	CountB int     `json:"count_b"`
+	RateA  float64 `json:"rate_a"`
+	RateB  float64 `json:"rate_b"`
+}
+
+// CompareReport lists the terms most over-represented in each side, strongest first
+type CompareReport struct {
+	Measure string            `json:"measure"`
+	WordsA  int               `json:"words_a"`
+	WordsB  int               `json:"words_b"`
+	A       []DistinctiveTerm `json:"a"`
+	B       []DistinctiveTerm `json:"b"`
+}
+
+// CompareOptions controls how two sets of counts are compared
+// Measure is "llr" for signed log-likelihood or "log-odds" for weighted log-odds with an informative Dirichlet prior
+// Prior is the total weight of that prior, spread over terms by their combined counts, 0 uses the combined word count
+// terms counted fewer than MinCount times on both sides together are ignored and Top limits each side, 0 keeps all
+type CompareOptions struct {
+	Measure  string
+	Prior    float64
+	MinCount int
+	Top      int
+}
+
+// signed log-likelihood of a term seen countA times in wordsA words and countB times in wordsB words
+// the sign says which side the term is over-represented in
+func signedLogLikelihood(countA, countB, wordsA, wordsB int) float64 {
+	// the contingency table is term or not against side A or side B
+	g2 := logLikelihoodRatio(countA, countA+countB, wordsA, wordsA+wordsB)
+	if float64(countA)*float64(wordsB) < float64(countB)*float64(wordsA) {
+		return -g2
+	}
+	return g2
+}
+
+// z-score of the weighted log-odds ratio with an informative Dirichlet prior (Monroe, Colaresi and Quinn 2008)
+// alpha is the term's prior count and alpha0 the prior count summed over every term
+func weightedLogOdds(countA, countB, wordsA, wordsB int, alpha, alpha0 float64) float64 {
+	a, b := float64(countA)+alpha, float64(countB)+alpha
+	delta := math.Log(a/(float64(wordsA)+alpha0-a)) - math.Log(b/(float64(wordsB)+alpha0-b))
+	return delta / math.Sqrt(1/a+1/b)
+}
+
+// compareTermCounts scores every term of a and b by how much more one side uses it than the other
+func compareTermCounts(a, b TermCountIndex, compare CompareOptions) (CompareReport, error) {
+	if compare.Measure != "llr" && compare.Measure != "log-odds" {
+		return CompareReport{}, errors.New("unknown measure " + compare.Measure + ", expected llr or log-odds")
+	}
+	if compare.Prior < 0 {
+		return CompareReport{}, errors.New("prior must not be negative")
+	}
+
+	report := CompareReport{Measure: compare.Measure}
+	for _, count := range a {
+		report.WordsA += count
+	}
+	for _, count := range b {
+		report.WordsB += count
+	}
+	if report.WordsA == 0 || report.WordsB == 0 {
+		return CompareReport{}, errors.New("both sides need counted words to compare")
+	}
+
+	total := report.WordsA + report.WordsB
+	alpha0 := compare.Prior
+	if alpha0 == 0 {
+		alpha0 = float64(total)
+	}
+
+	terms := make(map[string]struct{}, len(a)+len(b))
+	for term := range a {
+		terms[term] = struct{}{}
+	}
+	for term := range b {
+		terms[term] = struct{}{}
+	}
+
+	for term := range terms {
+		countA, countB := a[term], b[term]
+		if countA+countB < compare.MinCount {
+			continue
+		}
+
+		distinctive := DistinctiveTerm{
+			Term:   term,
+			CountA: countA,
+			CountB: countB,
+			RateA:  float64(countA) / float64(report.WordsA),
+			RateB:  float64(countB) / float64(report.WordsB),
+		}
+		if compare.Measure == "llr" {
+			distinctive.Score = signedLogLikelihood(countA, countB, report.WordsA, report.WordsB)
+		} else {
+			alpha := alpha0 * float64(countA+countB) / float64(total)
+			distinctive.Score = weightedLogOdds(countA, countB, report.WordsA, report.WordsB, alpha, alpha0)
+		}
+
+		switch {
+		case distinctive.Score > 0:
+			report.A = append(report.A, distinctive)
+		case distinctive.Score < 0:
+			report.B = append(report.B, distinctive)
+		}
+	}
+
+	sort.Slice(report.A, func(i, j int) bool {
+		if report.A[i].Score != report.A[j].Score {
+			return report.A[i].Score > report.A[j].Score
+		}
+		return report.A[i].Term < report.A[j].Term
+	})
+	sort.Slice(report.B, func(i, j int) bool {
+		if report.B[i].Score != report.B[j].Score {
+			return report.B[i].Score < report.B[j].Score
+		}
+		return report.B[i].Term < report.B[j].Term
+	})
+	if compare.Top > 0 {
+		report.A = report.A[:min(compare.Top, len(report.A))]
+		report.B = report.B[:min(compare.Top, len(report.B))]
+	}
+
+	return report, nil
+}
+
+// countDocuments adds up the term counts of every document, skipping documents with no words to count
+func countDocuments(docs []Document, opts ExtractOptions) (TermCountIndex, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	total := make(TermCountIndex)
+	for _, doc := range docs {
+		tci, err := countTerms(doc.Text, opts)
+		if err != nil {
+			continue
+		}
+		for term, count := range tci {
+			total[term] += count
+		}
+	}
+	return total, nil
+}
+
+// the columns written for a comparison by every format but json and plain
+var compareColumns = []string{"side", "term", "score", "count_a", "count_b", "rate_a", "rate_b"}
+
+// the values for one distinctive term in the same order as compareColumns
+func compareRow(side string, term DistinctiveTerm) []string {
+	return []string{
+		side,
+		term.Term,
+		strconv.FormatFloat(term.Score, 'f', 3, 64),
+		strconv.Itoa(term.CountA),
+		strconv.Itoa(term.CountB),
+		strconv.FormatFloat(term.RateA, 'f', 6, 64),
+		strconv.FormatFloat(term.RateB, 'f', 6, 64),
+	}
+}
+
+// writes a comparison in one of the formats accepted by newResultWriter
+// plain writes one term per line marked + for the first side and - for the second
+func writeCompareReport(w io.Writer, format string, report CompareReport) error {
+	writer, err := newResultWriter(format)
+	if err != nil {
+		return err
+	}
+
+	if report.A == nil {
+		report.A = []DistinctiveTerm{}
+	}
+	if report.B == nil {
+		report.B = []DistinctiveTerm{}
+	}
+	records := Records{
+		Columns: compareColumns,
+		Value:   report,
+		Footer:  []string{"", strconv.Itoa(report.WordsA) + " words in a, " + strconv.Itoa(report.WordsB) + " words in b"},
+	}
+	for _, term := range report.A {
+		records.Rows = append(records.Rows, compareRow("a", term))
+		records.Lines = append(records.Lines, "+"+term.Term)
+	}
+	for _, term := range report.B {
+		records.Rows = append(records.Rows, compareRow("b", term))
+		records.Lines = append(records.Lines, "-"+term.Term)
+	}
+	return writer.WriteRecords(w, records)
+}
+
+// runCompare is the compare command, it finds the terms that set one document or corpus apart from another
+func runCompare(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("compare", flag.ContinueOnError)
+	pathA := flags.String("a", "", "first document, JSONL file or directory of .txt documents")
+	pathB := flags.String("b", "", "second document, JSONL file or directory of .txt documents")
+	configPath := flags.String("config", "", "JSON, YAML or TOML config file")
+	format := flags.String("format", "table", "output format: "+strings.Join(outputFormatNames(), ", "))
+	measure := flags.String("measure", "llr", "how terms are scored: llr or log-odds")
+	prior := flags.Float64("prior", 0, "total weight of the log-odds prior, 0 uses the combined word count")
+	minCount := flags.Int("min-count", 2, "ignore terms counted fewer times on both sides together")
+	top := flags.Int("top", 15, "number of terms to print for each side, 0 for all")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *pathA == "" || *pathB == "" {
+		return errors.New("compare needs both -a and -b")
+	}
+
+	config, err := loadConfigWithFlags(*configPath, flags, map[string]func(*Config){
+		"format": func(c *Config) { c.Format = *format },
+	})
+	if err != nil {
+		return err
+	}
+	if config.Format == "" {
+		config.Format = *format
+	}
+	opts, err := config.options()
+	if err != nil {
+		return err
+	}
+
+	counts := make([]TermCountIndex, 2)
+	for i, path := range []string{*pathA, *pathB} {
+		docs, err := loadDocumentsOrText(path)
+		if err != nil {
+			return err
+		}
+		counts[i], err = countDocuments(docs, opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	report, err := compareTermCounts(counts[0], counts[1], CompareOptions{
+		Measure:  *measure,
+		Prior:    *prior,
+		MinCount: *minCount,
+		Top:      *top,
+	})
+	if err != nil {
+		return err
+	}
+	return writeCompareReport(stdout, config.Format, report)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/*
+This file tests for:
+- signed log-likelihood and weighted log-odds are zero for equal rates and flip sign when the sides swap
+- terms are split between the side they are over-represented in
+- the minimum count and top limit
+- rejecting bad options
+- the compare command reading a single document and a corpus
+*/
+func TestCompareMeasures(t *testing.T) {
+	// equal rates on both sides
+	if g2 := signedLogLikelihood(10, 20, 100, 200); math.Abs(g2) > 0.0001 {
+		t.Errorf("Expected 0 log-likelihood for equal rates, got %.4f", g2)
+	}
+	if z := weightedLogOdds(10, 20, 100, 200, 30, 300); math.Abs(z) > 0.0001 {
+		t.Errorf("Expected 0 log-odds for equal rates, got %.4f", z)
+	}
+
+	// swapping the sides flips the sign
+	forward, backward := signedLogLikelihood(30, 5, 100, 100), signedLogLikelihood(5, 30, 100, 100)
+	if forward <= 0 || math.Abs(forward+backward) > 0.0001 {
+		t.Errorf("Expected opposite log-likelihoods, got %.4f and %.4f", forward, backward)
+	}
+	forward, backward = weightedLogOdds(30, 5, 100, 100, 35, 200), weightedLogOdds(5, 30, 100, 100, 35, 200)
+	if forward <= 0 || math.Abs(forward+backward) > 0.0001 {
+		t.Errorf("Expected opposite log-odds, got %.4f and %.4f", forward, backward)
+	}
+}
+
+func TestCompareTermCounts(t *testing.T) {
+	a := TermCountIndex{"haskell": 20, "monads": 10, "code": 10, "rare": 1}
+	b := TermCountIndex{"rust": 20, "borrow": 10, "code": 10, "other": 1}
+
+	for _, measure := range []string{"llr", "log-odds"} {
+		report, err := compareTermCounts(a, b, CompareOptions{Measure: measure, MinCount: 2})
+		if err != nil {
+			t.Fatalf("%s: expected no error, got: %v", measure, err)
+		}
+		if report.WordsA != 41 || report.WordsB != 41 {
+			t.Errorf("%s: expected 41 words on each side, got %d and %d", measure, report.WordsA, report.WordsB)
+		}
+		if len(report.A) != 2 || report.A[0].Term != "haskell" || report.A[1].Term != "monads" {
+			t.Errorf("%s: expected haskell then monads for a, got %+v", measure, report.A)
+		}
+		if len(report.B) != 2 || report.B[0].Term != "rust" || report.B[1].Term != "borrow" {
+			t.Errorf("%s: expected rust then borrow for b, got %+v", measure, report.B)
+		}
+	}
+
+	// Test the top limit
+	report, _ := compareTermCounts(a, b, CompareOptions{Measure: "llr", Top: 1})
+	if len(report.A) != 1 || len(report.B) != 1 {
+		t.Errorf("Expected one term per side, got %d and %d", len(report.A), len(report.B))
+	}
+
+	// Test bad options are rejected
+	if _, err := compareTermCounts(a, b, CompareOptions{Measure: "chi2"}); err == nil {
+		t.Error("Expected error for unknown measure, got nil")
+	}
+	if _, err := compareTermCounts(a, b, CompareOptions{Measure: "log-odds", Prior: -1}); err == nil {
+		t.Error("Expected error for negative prior, got nil")
+	}
+	if _, err := compareTermCounts(a, TermCountIndex{}, CompareOptions{Measure: "llr"}); err == nil {
+		t.Error("Expected error for an empty side, got nil")
+	}
+}
+
+func TestRunCompare(t *testing.T) {
+	dir := t.TempDir()
+	single := filepath.Join(dir, "haskell.txt")
+	corpus := filepath.Join(dir, "corpus.jsonl")
+	os.WriteFile(single, []byte("Haskell monads. Haskell types. Haskell laziness and code."), 0644)
+	os.WriteFile(corpus, []byte(`{"text":"Rust borrow checker and code."}`+"\n"+`{"text":"Rust ownership and Rust code."}`+"\n"), 0644)
+
+	var out bytes.Buffer
+	if err := runCompare([]string{"-a", single, "-b", corpus, "-format", "plain", "-top", "1"}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if out.String() != "+haskell\n-rust\n" {
+		t.Errorf("Expected +haskell and -rust, got %q", out.String())
+	}
+
+	if err := runCompare([]string{"-a", single}, &out); err == nil {
+		t.Error("Expected error without -b, got nil")
+	}
+	err := runCompare([]string{"-a", single, "-b", filepath.Join(dir, "missing.txt")}, &out)
+	if err == nil || !strings.Contains(err.Error(), "missing.txt") {
+		t.Errorf("Expected error naming the missing file, got: %v", err)
+	}
+}
@@ -0,0 +1,489 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every setting for a run so deployments can keep them in a file
+// it can be written as JSON, YAML or TOML using the json tag names as keys
+type Config struct {
+	Algorithm   string            `json:"algorithm"`
+	NumKeywords int               `json:"keywords"`
+	Tokenizer   TokenizerConfig   `json:"tokenizer"`
+	TextFilters []string          `json:"text_filters"` // nil uses defaultFilterNames, an empty list turns them off
+	Filters     TokenFilterConfig `json:"filters"`      // filters.stopwords lists the stopword files
+	Weights     WeightsConfig     `json:"weights"`
+	Vocabulary  VocabularyConfig  `json:"vocabulary"`
+	Synonyms    SynonymConfig     `json:"synonyms"`
+	Diversity   DiversityConfig   `json:"diversity"`
+	Selection   SelectionOptions  `json:"selection"` // an empty mode keeps the number of keywords set by keywords
+	Sentences   bool              `json:"sentences"` // list the sentences each keyword occurs in
+	Format      string            `json:"format"`    // one of the names in resultWriters, empty uses the command's own default
+}
+
+// TokenizerConfig controls how text is split into words
+type TokenizerConfig struct {
+	Pattern string `json:"pattern"` // regular expression matching the text between words, empty uses defaultWordSplitter
+}
+
+// WeightsConfig describes positional weighting, the zero value turns it off
+type WeightsConfig struct {
+	Decay               string  `json:"decay"`       // linear, exponential or inverse, empty for none
+	DecayParam          float64 `json:"decay_param"` // the floor, rate or scale of the decay curve
+	FirstParagraphBoost float64 `json:"first_paragraph_boost"`
+}
+
+// the settings used when there is no config file
+func defaultConfig() Config {
+	return Config{Algorithm: "tf", NumKeywords: 10}
+}
+
+// loadConfig reads and validates a config file
+// keys missing from the file keep their default values
+func loadConfig(path string) (Config, error) {
+	config, err := readConfig(path)
+	if err != nil {
+		return Config{}, err
+	}
+	if err := config.validate(); err != nil {
+		return Config{}, errors.New(path + ": " + err.Error())
+	}
+	return config, nil
+}
+
+// reads a config file over the defaults without validating it, picking the format from the file extension
+func readConfig(path string) (Config, error) {
+	var parse func([]byte) (map[string]any, error)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		parse = parseJSONConfig
+	case ".yaml", ".yml":
+		parse = parseYAMLConfig
+	case ".toml":
+		parse = parseTOMLConfig
+	default:
+		return Config{}, errors.New(path + ": unknown config format, expected .json, .yaml, .yml or .toml")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, errors.New("Error opening file, check file path: " + err.Error())
+	}
+
+	values, err := parse(data)
+	if err != nil {
+		return Config{}, errors.New(path + ": " + err.Error())
+	}
+
+	config := defaultConfig()
+	if err := decodeConfigValue("", values, reflect.ValueOf(&config).Elem()); err != nil {
+		return Config{}, errors.New(path + ": " + err.Error())
+	}
+	return config, nil
+}
+
+// parses a JSON config into nested map[string]any values so they can be checked against the Config struct
+// numbers are kept as json.Number so integers are not rounded through float64
+func parseJSONConfig(data []byte) (map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var values map[string]any
+	if err := decoder.Decode(&values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// parses a YAML config into the same values parseJSONConfig gives
+func parseYAMLConfig(data []byte) (map[string]any, error) {
+	var values map[string]any
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	return jsonConfigTable("", values)
+}
+
+// parses a TOML config into the same values parseJSONConfig gives
+func parseTOMLConfig(data []byte) (map[string]any, error) {
+	var values map[string]any
+	if _, err := toml.Decode(string(data), &values); err != nil {
+		return nil, err
+	}
+	return jsonConfigTable("", values)
+}
+
+// converts a table parsed from YAML or TOML, see jsonConfigValue
+func jsonConfigTable(path string, values map[string]any) (map[string]any, error) {
+	table := make(map[string]any, len(values))
+	for key, value := range values {
+		converted, err := jsonConfigValue(joinKey(path, key), value)
+		if err != nil {
+			return nil, err
+		}
+		table[key] = converted
+	}
+	return table, nil
+}
+
+// converts a value parsed from YAML or TOML into the kind parseJSONConfig gives, so every format is checked
+// against the Config struct by the same code: tables become map[string]any, lists []any and numbers json.Number
+// values with no JSON counterpart, such as dates, are left for decodeConfigValue to reject
+func jsonConfigValue(path string, value any) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		return jsonConfigTable(path, v)
+	case map[any]any:
+		// yaml only uses this when a key is not a string
+		table := make(map[string]any, len(v))
+		for key, item := range v {
+			name, ok := key.(string)
+			if !ok {
+				return nil, errors.New(keyName(path) + ": keys must be strings, got " + describeConfigValue(key))
+			}
+			table[name] = item
+		}
+		return jsonConfigTable(path, table)
+	case []map[string]any:
+		// toml arrays of tables
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return jsonConfigValue(path, items)
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			converted, err := jsonConfigValue(path+"["+strconv.Itoa(i)+"]", item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = converted
+		}
+		return items, nil
+	case int:
+		return json.Number(strconv.Itoa(v)), nil
+	case int64:
+		return json.Number(strconv.FormatInt(v, 10)), nil
+	case uint64:
+		return json.Number(strconv.FormatUint(v, 10)), nil
+	case float64:
+		return json.Number(strconv.FormatFloat(v, 'f', -1, 64)), nil
+	}
+	return value, nil
+}
+
+// copies a parsed value into target, naming the key path of any value that does not fit
+func decodeConfigValue(path string, value any, target reflect.Value) error {
+	// a key with no value keeps its default
+	if value == nil {
+		return nil
+	}
+	fail := func(expected string) error {
+		return errors.New(keyName(path) + ": expected " + expected + ", got " + describeConfigValue(value))
+	}
+
+	switch target.Kind() {
+	case reflect.Struct:
+		values, ok := value.(map[string]any)
+		if !ok {
+			return fail("a table of keys")
+		}
+		fields := configFields(target.Type())
+
+		// sorted so the first bad key reported is always the same one
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			index, ok := fields[key]
+			if !ok {
+				names := make([]string, 0, len(fields))
+				for name := range fields {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				return errors.New(keyName(joinKey(path, key)) + ": unknown key, expected one of: " + strings.Join(names, ", "))
+			}
+			if err := decodeConfigValue(joinKey(path, key), values[key], target.Field(index)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Slice:
+		items, ok := value.([]any)
+		if !ok {
+			return fail("a list")
+		}
+		slice := reflect.MakeSlice(target.Type(), len(items), len(items))
+		for i, item := range items {
+			if item == nil {
+				return errors.New(keyName(path) + "[" + strconv.Itoa(i) + "]: missing value")
+			}
+			if err := decodeConfigValue(path+"["+strconv.Itoa(i)+"]", item, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		target.Set(slice)
+
+	case reflect.Pointer:
+		// a pointer is set only when its key is given, so a zero value can mean something
+		pointer := reflect.New(target.Type().Elem())
+		if err := decodeConfigValue(path, value, pointer.Elem()); err != nil {
+			return err
+		}
+		target.Set(pointer)
+
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return fail("a string")
+		}
+		target.SetString(s)
+
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return fail("true or false")
+		}
+		target.SetBool(b)
+
+	case reflect.Int:
+		n, ok := value.(json.Number)
+		if !ok {
+			return fail("a whole number")
+		}
+		i, err := n.Int64()
+		if err != nil {
+			return fail("a whole number")
+		}
+		target.SetInt(i)
+
+	case reflect.Float64:
+		n, ok := value.(json.Number)
+		if !ok {
+			return fail("a number")
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return fail("a number")
+		}
+		target.SetFloat(f)
+
+	default:
+		return errors.New(keyName(path) + ": unsupported setting type " + target.Type().String())
+	}
+	return nil
+}
+
+// maps each json tag name of a struct to its field index
+func configFields(t reflect.Type) map[string]int {
+	fields := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+	return fields
+}
+
+// joins a key onto a dotted key path
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// the path shown in errors, the top level has no key of its own
+func keyName(path string) string {
+	if path == "" {
+		return "config"
+	}
+	return path
+}
+
+// describes a parsed value for error messages
+func describeConfigValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return "string " + `"` + v + `"`
+	case map[string]any:
+		return "a table"
+	case []any:
+		return "a list"
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	case json.Number:
+		return v.String()
+	}
+	b, _ := json.Marshal(value)
+	return string(b)
+}
+
+// validate checks every setting, naming the key of the first bad one
+func (c Config) validate() error {
+	_, err := c.options()
+	return err
+}
+
+// options builds the extraction options the config describes
+func (c Config) options() (ExtractOptions, error) {
+	opts, err := newAlgorithmOptions(c.Algorithm, c.NumKeywords)
+	if err != nil {
+		return ExtractOptions{}, errors.New("algorithm: " + err.Error())
+	}
+	if c.NumKeywords < 1 {
+		return ExtractOptions{}, errors.New("keywords: must be at least 1")
+	}
+
+	if c.Tokenizer.Pattern != "" {
+		splitter, err := regexp.Compile(c.Tokenizer.Pattern)
+		if err != nil {
+			return ExtractOptions{}, errors.New("tokenizer.pattern: " + err.Error())
+		}
+		opts.WordSplitter = splitter
+	}
+
+	if c.TextFilters != nil {
+		chain, err := newFilterChain(c.TextFilters)
+		if err != nil {
+			return ExtractOptions{}, errors.New("text_filters: " + err.Error())
+		}
+		opts.Filters = chain
+	}
+
+	// the default stopwords are only needed when the config does not list its own files
+	var stopwords map[string]struct{}
+	if len(c.Filters.Stopwords) == 0 {
+		stopwords, err = LoadStopwords(defaultStopwordsPath)
+		if err != nil {
+			return ExtractOptions{}, err
+		}
+	}
+	tokenFilters, err := c.Filters.build(stopwords)
+	if err != nil {
+		return ExtractOptions{}, errors.New("filters: " + err.Error())
+	}
+	opts.TokenFilters = &tokenFilters
+	// set so extractKeywords does not load the default file again, the filters hold the stopwords in use
+	opts.Stopwords = stopwords
+	if opts.Stopwords == nil {
+		opts.Stopwords = map[string]struct{}{}
+	}
+
+	if c.Weights.Decay != "" || c.Weights.FirstParagraphBoost != 0 {
+		weighting := PositionWeighting{FirstParagraphBoost: c.Weights.FirstParagraphBoost}
+		if c.Weights.Decay != "" {
+			curve, err := newDecayCurve(c.Weights.Decay, c.Weights.DecayParam)
+			if err != nil {
+				return ExtractOptions{}, errors.New("weights.decay: " + err.Error())
+			}
+			weighting.FirstOccurrence = curve
+		}
+		// a boost below 1 would lower first paragraph terms rather than raise them
+		if c.Weights.FirstParagraphBoost < 0 || (c.Weights.FirstParagraphBoost > 0 && c.Weights.FirstParagraphBoost < 1) {
+			return ExtractOptions{}, errors.New("weights.first_paragraph_boost: must be 0 or at least 1")
+		}
+		opts.Position = &weighting
+	}
+
+	if c.Vocabulary.Path != "" {
+		vocabulary, err := loadVocabulary(c.Vocabulary.Path, c.Vocabulary.Language)
+		if err != nil {
+			return ExtractOptions{}, errors.New("vocabulary.path: " + err.Error())
+		}
+		opts.Vocabulary = &VocabularyOptions{Vocabulary: vocabulary, Restrict: c.Vocabulary.Restrict}
+	} else if c.Vocabulary.Restrict {
+		return ExtractOptions{}, errors.New("vocabulary.restrict: needs a vocabulary.path")
+	}
+
+	if len(c.Synonyms.Files) > 0 || c.Synonyms.Acronyms {
+		synonyms := SynonymOptions{Dictionary: make(map[string]string), DetectAcronyms: c.Synonyms.Acronyms}
+		// later files win where two map the same alias
+		for _, path := range c.Synonyms.Files {
+			loaded, err := loadSynonyms(path)
+			if err != nil {
+				return ExtractOptions{}, errors.New("synonyms.files: " + err.Error())
+			}
+			for alias, canonical := range loaded {
+				synonyms.Dictionary[alias] = canonical
+			}
+		}
+		opts.Synonyms = &synonyms
+	}
+
+	if c.Diversity.Lambda != nil && (*c.Diversity.Lambda < 0 || *c.Diversity.Lambda > 1) {
+		return ExtractOptions{}, errors.New("diversity.lambda: must be between 0 and 1")
+	}
+	if c.Diversity.Candidates < 0 {
+		return ExtractOptions{}, errors.New("diversity.candidates: must not be negative")
+	}
+	if c.Diversity.Lambda != nil {
+		opts.Diversity = &DiversityOptions{Lambda: *c.Diversity.Lambda, Candidates: c.Diversity.Candidates}
+	}
+
+	if c.Selection.Mode != "" {
+		if err := c.Selection.validate(); err != nil {
+			return ExtractOptions{}, errors.New("selection: " + err.Error())
+		}
+		selection := c.Selection
+		opts.Selection = &selection
+	}
+	opts.Sentences = c.Sentences
+
+	if c.Format != "" {
+		if _, err := newResultWriter(c.Format); err != nil {
+			return ExtractOptions{}, errors.New("format: " + err.Error())
+		}
+	}
+
+	return opts, nil
+}
+
+// loads the config file at path, or the defaults when path is empty, then applies any flags set on
+// the command line over it
+// overrides maps a flag name to the function copying its value into the config
+func loadConfigWithFlags(path string, flags *flag.FlagSet, overrides map[string]func(*Config)) (Config, error) {
+	config := defaultConfig()
+	if path != "" {
+		loaded, err := readConfig(path)
+		if err != nil {
+			return Config{}, err
+		}
+		config = loaded
+	}
+
+	// only flags given on the command line override the file, so flag defaults do not mask it
+	flags.Visit(func(f *flag.Flag) {
+		if override, ok := overrides[f.Name]; ok {
+			override(&config)
+		}
+	})
+	if err := config.validate(); err != nil {
+		if path != "" {
+			return Config{}, errors.New(path + ": " + err.Error())
+		}
+		return Config{}, err
+	}
+	return config, nil
+}
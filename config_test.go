@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+/*
+This file tests for:
+- the YAML, TOML and JSON readers produce the same values
+- parse errors name the line and other formats are rejected
+- unknown keys and wrong types name the key path
+- missing keys keep their defaults
+- building extraction options from a config
+- flags given on the command line override the file
+- the extract command reading a config and listing keyword sentences
+*/
+
+// writes content to a file called name in a temporary directory and returns its path
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	return path
+}
+
+func TestConfigFormats(t *testing.T) {
+	yaml := `# keyword settings
+algorithm: collocation
+"keywords": 5
+tokenizer:
+  pattern: >-
+    [^\p{L}\p{N}]+
+text_filters: [urls, "citations"]
+filters:
+  min_length: 4
+  numeric: drop-any
+  allow:
+    - C#
+    - 'io'
+weights:
+  decay: linear
+  decay_param: 0.5
+  first_paragraph_boost: ~
+`
+	toml := `# keyword settings
+algorithm = "collocation"
+"keywords" = 5
+tokenizer.pattern = '[^\p{L}\p{N}]+'
+text_filters = [
+  "urls",
+  "citations",
+]
+weights = {decay = "linear", decay_param = 0.5}
+
+[filters]
+min_length = 4
+numeric = "drop-any"
+allow = ["C#", 'io']
+`
+	json := `{
+	"algorithm": "collocation",
+	"keywords": 5,
+	"tokenizer": {"pattern": "[^\\p{L}\\p{N}]+"},
+	"text_filters": ["urls", "citations"],
+	"filters": {"min_length": 4, "numeric": "drop-any", "allow": ["C#", "io"]},
+	"weights": {"decay": "linear", "decay_param": 0.5, "first_paragraph_boost": null}
+}`
+
+	expected := defaultConfig()
+	expected.Algorithm = "collocation"
+	expected.NumKeywords = 5
+	expected.Tokenizer.Pattern = `[^\p{L}\p{N}]+`
+	expected.TextFilters = []string{"urls", "citations"}
+	expected.Filters = TokenFilterConfig{MinLength: 4, Numeric: "drop-any", Allow: []string{"C#", "io"}}
+	expected.Weights = WeightsConfig{Decay: "linear", DecayParam: 0.5}
+
+	for name, content := range map[string]string{"config.yaml": yaml, "config.toml": toml, "config.json": json} {
+		config, err := loadConfig(writeConfigFile(t, name, content))
+		if err != nil {
+			t.Errorf("%s: expected no error, got: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(config, expected) {
+			t.Errorf("%s: expected %+v, got %+v", name, expected, config)
+		}
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		message string
+	}{
+		{"bad.yaml", "algorithm: tf\n  keywords: 5\n", "yaml: line 2"},
+		{"bad.toml", "algorithm = tf\n", "toml: line 1"},
+		{"bad.toml", "[filters]\n[filters]\n", "toml: line 2"},
+		{"bad.json", `{"keywords": 5,}`, "invalid character"},
+		{"bad.yaml", "filters:\n  min_lenght: 4\n", "filters.min_lenght: unknown key"},
+		{"bad.toml", "[filters]\nmin_lenght = 4\n", "filters.min_lenght: unknown key"},
+		{"bad.toml", "[weights]\ndecay_param = \"high\"\n", `weights.decay_param: expected a number, got string "high"`},
+		{"bad.yml", "keywords: 2.5\n", "keywords: expected a whole number, got 2.5"},
+		{"bad.yaml", "filters:\n  1: 4\n", "filters: keys must be strings, got 1"},
+		{"bad.toml", "[[text_filters]]\nname = \"urls\"\n", "text_filters[0]: expected a string, got a table"},
+		{"bad.json", `{"filters": {"min_lenght": 4}}`, "filters.min_lenght: unknown key"},
+		{"bad.json", `{"weights": {"decay_param": "high"}}`, `weights.decay_param: expected a number, got string "high"`},
+		{"bad.json", `{"text_filters": "urls"}`, "text_filters: expected a list"},
+		{"bad.json", `{"keywords": 2.5}`, "keywords: expected a whole number, got 2.5"},
+		{"bad.json", `{"algorithm": "bogus"}`, "algorithm: unknown algorithm bogus"},
+		{"bad.json", `{"filters": {"numeric": "sometimes"}}`, "filters: unknown numeric policy"},
+		{"bad.json", `{"weights": {"decay": "cubic"}}`, "weights.decay: unknown decay curve"},
+		{"bad.json", `{"weights": {"first_paragraph_boost": 0.5}}`, "weights.first_paragraph_boost: must be 0 or at least 1"},
+		{"bad.json", `{"tokenizer": {"pattern": "["}}`, "tokenizer.pattern:"},
+		{"bad.json", `{"format": "xml"}`, "format: unknown output format xml"},
+		{"bad.ini", "algorithm=tf\n", "unknown config format"},
+	}
+	for _, c := range cases {
+		_, err := loadConfig(writeConfigFile(t, c.name, c.content))
+		if err == nil {
+			t.Errorf("Expected error containing %q, got nil", c.message)
+			continue
+		}
+		if !strings.Contains(err.Error(), c.message) {
+			t.Errorf("Expected error containing %q, got: %v", c.message, err)
+		}
+	}
+}
+
+func TestConfigOptions(t *testing.T) {
+	// Test the defaults behave like getStringKeywords
+	t.Run("Defaults", func(t *testing.T) {
+		opts, err := defaultConfig().options()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if opts.NumKeywords != 10 || opts.Position != nil || opts.Filters != nil {
+			t.Errorf("Unexpected default options: %+v", opts)
+		}
+		if opts.TokenFilters.Keep("the") || opts.TokenFilters.Keep("2010") || !opts.TokenFilters.Keep("haskell") {
+			t.Error("Expected the default stopwords and numeric policy")
+		}
+	})
+
+	// Test each section reaches the options
+	t.Run("Sections", func(t *testing.T) {
+		stopwords := writeConfigFile(t, "stop.txt", "haskell\n")
+		config := defaultConfig()
+		config.Tokenizer.Pattern = `\s+`
+		config.TextFilters = []string{}
+		config.Filters = TokenFilterConfig{Stopwords: []string{stopwords}}
+		config.Weights = WeightsConfig{FirstParagraphBoost: 2}
+
+		opts, err := config.options()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if opts.WordSplitter.String() != `\s+` {
+			t.Errorf("Expected the tokenizer pattern, got %v", opts.WordSplitter)
+		}
+		if opts.Filters == nil || len(opts.Filters) != 0 {
+			t.Errorf("Expected an empty text filter chain, got %d filters", len(opts.Filters))
+		}
+		if opts.TokenFilters.Keep("haskell") || !opts.TokenFilters.Keep("the") {
+			t.Error("Expected only the stopwords from the configured file")
+		}
+		if opts.Position == nil || opts.Position.FirstParagraphBoost != 2 {
+			t.Errorf("Expected a first paragraph boost of 2, got %+v", opts.Position)
+		}
+	})
+}
+
+func TestLoadConfigWithFlags(t *testing.T) {
+	path := writeConfigFile(t, "config.json", `{"algorithm": "collocation", "keywords": 5}`)
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	algorithm := flags.String("algorithm", "tf", "")
+	n := flags.Int("n", 10, "")
+	flags.Parse([]string{"-n", "3"})
+
+	config, err := loadConfigWithFlags(path, flags, map[string]func(*Config){
+		"algorithm": func(c *Config) { c.Algorithm = *algorithm },
+		"n":         func(c *Config) { c.NumKeywords = *n },
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	// -algorithm was not given so the file wins over the flag default
+	if config.Algorithm != "collocation" || config.NumKeywords != 3 {
+		t.Errorf("Expected collocation with 3 keywords, got %s with %d", config.Algorithm, config.NumKeywords)
+	}
+
+	// flags are validated too
+	flags.Parse([]string{"-n", "0"})
+	if _, err := loadConfigWithFlags(path, flags, map[string]func(*Config){"n": func(c *Config) { c.NumKeywords = *n }}); err == nil {
+		t.Error("Expected error for zero keywords, got nil")
+	}
+}
+
+func TestRunExtract(t *testing.T) {
+	input := writeConfigFile(t, "input.txt", "Haskell is lazy. Haskell has monads. Monads wrap effects in Haskell.")
+	config := writeConfigFile(t, "config.json", `{"keywords": 1}`)
+
+	var out bytes.Buffer
+	if err := runExtract([]string{"-config", config, input}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if out.String() != "haskell\n" {
+		t.Errorf("Expected 'haskell', got %q", out.String())
+	}
+
+	out.Reset()
+	if err := runExtract([]string{"-config", config, "-n", "2", input}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if out.String() != "haskell\nmonads\n" {
+		t.Errorf("Expected 'haskell' and 'monads', got %q", out.String())
+	}
+
+	// Test -sentences lists where each keyword occurs in the json format
+	out.Reset()
+	if err := runExtract([]string{"-config", config, "-sentences", "-format", "json", input}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(out.String(), `"text": "Monads wrap effects in Haskell."`) {
+		t.Errorf("Expected the sentences of 'haskell', got %s", out.String())
+	}
+}
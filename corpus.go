@@ -0,0 +1,212 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CorpusKeyword is a keyword ranked across a whole collection of documents
+// Documents is how many documents had it among their top keywords and Coverage is that as a fraction of the corpus
+// Score and Count are summed over those documents, Examples holds the IDs of the documents it scored highest in
+type CorpusKeyword struct {
+	Term      string   `json:"term"`
+	Documents int      `json:"documents"`
+	Coverage  float64  `json:"coverage"`
+	Score     float64  `json:"score"`
+	Count     int      `json:"count"`
+	Examples  []string `json:"examples"`
+}
+
+// CorpusReport is the corpus-level ranking, Skipped counts documents no keywords could be found in
+type CorpusReport struct {
+	Documents int             `json:"documents"`
+	Skipped   int             `json:"skipped"`
+	Keywords  []CorpusKeyword `json:"keywords"`
+}
+
+// CorpusOptions controls how per-document keywords are combined
+// RankBy is "coverage" to rank by document count then summed score, or "score" for the reverse
+// MinDocuments drops keywords found in fewer documents, Examples is the number of example documents kept
+type CorpusOptions struct {
+	RankBy       string
+	MinDocuments int
+	Examples     int
+}
+
+// one document a keyword was found in, kept for choosing examples
+type corpusExample struct {
+	id    string
+	score float64
+}
+
+// aggregateKeywords extracts keywords from every document with opts and ranks them across the corpus
+func aggregateKeywords(docs []Document, opts ExtractOptions, corpus CorpusOptions) (CorpusReport, error) {
+	if corpus.RankBy != "coverage" && corpus.RankBy != "score" {
+		return CorpusReport{}, errors.New("unknown ranking " + corpus.RankBy + ", expected coverage or score")
+	}
+	if corpus.Examples < 0 {
+		return CorpusReport{}, errors.New("number of examples must not be negative")
+	}
+
+	// load the stopwords once rather than for every document
+	if opts.Stopwords == nil {
+		stopwords, err := LoadStopwords(defaultStopwordsPath)
+		if err != nil {
+			return CorpusReport{}, err
+		}
+		opts.Stopwords = stopwords
+	}
+
+	report := CorpusReport{}
+	totals := make(map[string]*CorpusKeyword)
+	examples := make(map[string][]corpusExample)
+	for _, doc := range docs {
+		keywords, err := extractKeywords(doc.Text, opts)
+		if err != nil {
+			report.Skipped++
+			continue
+		}
+		report.Documents++
+
+		for _, keyword := range keywords {
+			total, ok := totals[keyword.Term]
+			if !ok {
+				total = &CorpusKeyword{Term: keyword.Term}
+				totals[keyword.Term] = total
+			}
+			total.Documents++
+			total.Score += keyword.Score
+			total.Count += keyword.Count
+			examples[keyword.Term] = append(examples[keyword.Term], corpusExample{id: doc.ID, score: keyword.Score})
+		}
+	}
+
+	for term, total := range totals {
+		if total.Documents < corpus.MinDocuments {
+			continue
+		}
+		total.Coverage = float64(total.Documents) / float64(report.Documents)
+		total.Examples = topExamples(examples[term], corpus.Examples)
+		report.Keywords = append(report.Keywords, *total)
+	}
+
+	sort.Slice(report.Keywords, func(i, j int) bool {
+		a, b := report.Keywords[i], report.Keywords[j]
+		if corpus.RankBy == "score" && a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Documents != b.Documents {
+			return a.Documents > b.Documents
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Term < b.Term
+	})
+	return report, nil
+}
+
+// returns the IDs of the n documents a keyword scored highest in, ties kept in corpus order
+func topExamples(found []corpusExample, n int) []string {
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].score > found[j].score
+	})
+	ids := make([]string, 0, n)
+	for i := 0; i < n && i < len(found); i++ {
+		ids = append(ids, found[i].id)
+	}
+	return ids
+}
+
+// the columns written for a corpus report by every format but json and plain
+var corpusColumns = []string{"rank", "term", "documents", "coverage", "score", "count", "examples"}
+
+// the values for one corpus keyword in the same order as corpusColumns
+func corpusRow(rank int, keyword CorpusKeyword) []string {
+	return []string{
+		strconv.Itoa(rank),
+		keyword.Term,
+		strconv.Itoa(keyword.Documents),
+		strconv.FormatFloat(keyword.Coverage, 'f', 4, 64),
+		strconv.FormatFloat(keyword.Score, 'f', 6, 64),
+		strconv.Itoa(keyword.Count),
+		strings.Join(keyword.Examples, " "),
+	}
+}
+
+// writes a corpus report in one of the formats accepted by newResultWriter
+func writeCorpusReport(w io.Writer, format string, report CorpusReport) error {
+	writer, err := newResultWriter(format)
+	if err != nil {
+		return err
+	}
+
+	if report.Keywords == nil {
+		report.Keywords = []CorpusKeyword{}
+	}
+	records := Records{
+		Columns: corpusColumns,
+		Value:   report,
+		Footer:  []string{"", strconv.Itoa(report.Documents) + " documents, " + strconv.Itoa(report.Skipped) + " skipped"},
+	}
+	for i, keyword := range report.Keywords {
+		records.Rows = append(records.Rows, corpusRow(i+1, keyword))
+		records.Lines = append(records.Lines, keyword.Term)
+	}
+	return writer.WriteRecords(w, records)
+}
+
+// runCorpus is the corpus command, it ranks keywords across every document in a dataset
+func runCorpus(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("corpus", flag.ContinueOnError)
+	dataset := flags.String("dataset", "", "JSONL file or directory of .txt documents")
+	configPath := flags.String("config", "", "JSON, YAML or TOML config file")
+	algorithm := flags.String("algorithm", "tf", "algorithm to use: "+strings.Join(algorithmNames(), ", "))
+	numKeywords := flags.Int("n", 10, "number of keywords taken from each document")
+	format := flags.String("format", "table", "output format: "+strings.Join(outputFormatNames(), ", "))
+	top := flags.Int("top", 20, "number of corpus keywords to print, 0 for all")
+	rankBy := flags.String("rank", "coverage", "rank by document coverage or summed score: coverage or score")
+	minDocuments := flags.Int("min-docs", 1, "drop keywords found in fewer documents")
+	numExamples := flags.Int("examples", 3, "number of example documents listed per keyword")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *dataset == "" {
+		return errors.New("corpus needs a -dataset")
+	}
+
+	config, err := loadConfigWithFlags(*configPath, flags, map[string]func(*Config){
+		"algorithm": func(c *Config) { c.Algorithm = *algorithm },
+		"n":         func(c *Config) { c.NumKeywords = *numKeywords },
+		"format":    func(c *Config) { c.Format = *format },
+	})
+	if err != nil {
+		return err
+	}
+	if config.Format == "" {
+		config.Format = *format
+	}
+	opts, err := config.options()
+	if err != nil {
+		return err
+	}
+
+	docs, err := loadDocuments(*dataset)
+	if err != nil {
+		return err
+	}
+
+	report, err := aggregateKeywords(docs, opts, CorpusOptions{RankBy: *rankBy, MinDocuments: *minDocuments, Examples: *numExamples})
+	if err != nil {
+		return err
+	}
+	if *top > 0 && len(report.Keywords) > *top {
+		report.Keywords = report.Keywords[:*top]
+	}
+
+	return writeCorpusReport(stdout, config.Format, report)
+}
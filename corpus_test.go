@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/*
+This file tests for:
+- document coverage and summed scores across a corpus
+- ranking by coverage or by score
+- the minimum document count and number of examples
+- documents with no keywords are skipped
+- the corpus command reading a directory of texts
+*/
+func TestAggregateKeywords(t *testing.T) {
+	docs := []Document{
+		{ID: "a", Text: "haskell haskell monads"},
+		{ID: "b", Text: "haskell lambda lambda lambda lambda"},
+		{ID: "c", Text: "haskell types"},
+		{ID: "d", Text: "a an"},
+	}
+	opts := ExtractOptions{NumKeywords: 5, Stopwords: map[string]struct{}{}}
+
+	// Test coverage, summed scores and examples
+	t.Run("Coverage", func(t *testing.T) {
+		report, err := aggregateKeywords(docs, opts, CorpusOptions{RankBy: "coverage", MinDocuments: 1, Examples: 2})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if report.Documents != 3 || report.Skipped != 1 {
+			t.Errorf("Expected 3 documents and 1 skipped, got %d and %d", report.Documents, report.Skipped)
+		}
+
+		top := report.Keywords[0]
+		if top.Term != "haskell" || top.Documents != 3 || top.Count != 4 {
+			t.Fatalf("Expected haskell in 3 documents counted 4 times, got %+v", top)
+		}
+		if math.Abs(top.Coverage-1) > 0.0001 {
+			t.Errorf("Expected full coverage, got %.4f", top.Coverage)
+		}
+		// haskell scores 2/3 in a, 1/5 in b and 1/2 in c
+		if math.Abs(top.Score-(2.0/3+1.0/5+1.0/2)) > 0.0001 {
+			t.Errorf("Expected summed score %.4f, got %.4f", 2.0/3+1.0/5+1.0/2, top.Score)
+		}
+		if strings.Join(top.Examples, ",") != "a,c" {
+			t.Errorf("Expected examples a and c, got %v", top.Examples)
+		}
+	})
+
+	// Test ranking by summed score
+	t.Run("Score", func(t *testing.T) {
+		report, err := aggregateKeywords(docs, opts, CorpusOptions{RankBy: "score", MinDocuments: 1})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if report.Keywords[0].Term != "haskell" || report.Keywords[1].Term != "lambda" {
+			t.Errorf("Expected haskell then lambda, got %s then %s", report.Keywords[0].Term, report.Keywords[1].Term)
+		}
+	})
+
+	// Test the minimum document count
+	t.Run("MinDocuments", func(t *testing.T) {
+		report, err := aggregateKeywords(docs, opts, CorpusOptions{RankBy: "coverage", MinDocuments: 2})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(report.Keywords) != 1 {
+			t.Errorf("Expected only haskell, got %v", report.Keywords)
+		}
+	})
+
+	// Test bad options are rejected
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := aggregateKeywords(docs, opts, CorpusOptions{RankBy: "popularity"}); err == nil {
+			t.Error("Expected error for unknown ranking, got nil")
+		}
+		if _, err := aggregateKeywords(docs, opts, CorpusOptions{RankBy: "score", Examples: -1}); err == nil {
+			t.Error("Expected error for negative examples, got nil")
+		}
+	})
+}
+
+func TestRunCorpus(t *testing.T) {
+	dir := t.TempDir()
+	texts := map[string]string{
+		"one.txt": "Haskell is a lazy language. Haskell has monads.",
+		"two.txt": "Haskell compilers are fast. GHC compiles Haskell.",
+	}
+	for name, text := range texts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := runCorpus([]string{"-dataset", dir, "-format", "json", "-top", "1"}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	var report CorpusReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("Expected valid JSON, got: %v", err)
+	}
+	if report.Documents != 2 || len(report.Keywords) != 1 || report.Keywords[0].Term != "haskell" {
+		t.Errorf("Expected haskell as the only keyword across 2 documents, got %+v", report)
+	}
+
+	out.Reset()
+	if err := runCorpus([]string{"-dataset", dir}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "rank") {
+		t.Errorf("Expected a table by default, got:\n%s", out.String())
+	}
+}
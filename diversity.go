@@ -0,0 +1,170 @@
+package main
+
+import (
+	"math"
+	"regexp"
+	"strings"
+)
+
+// DiversityOptions re-ranks keywords by Maximal Marginal Relevance so near duplicates such as "function"
+// and "functions" do not crowd out other terms
+// each pick maximises Lambda times the keyword's score, scaled so the best is 1, less 1 - Lambda times its
+// highest similarity to a keyword already picked, so 1 ranks by score alone and lower values favour variety
+// keywords are picked from the Candidates best scoring ones, 0 uses three times the number asked for
+type DiversityOptions struct {
+	Lambda     float64
+	Candidates int
+}
+
+// DiversityConfig turns on diversity re-ranking from a config file
+type DiversityConfig struct {
+	Lambda     *float64 `json:"lambda"` // between 0 and 1, nil leaves re-ranking off
+	Candidates int      `json:"candidates"`
+}
+
+// how alike two terms are, from 0 to 1, taking the stronger of two signals
+//   - the share of stemmed words they have in common, so "functions" is like "function" and "type classes"
+//     is half like "types"
+//   - how often they share a sentence compared with how often they appear, for terms used together
+type termSimilarity struct {
+	sentences map[string]map[int]struct{} // the sentences each word appears in
+}
+
+// indexes the sentences of content so co-occurrence can be looked up
+func newTermSimilarity(content string, wordSplitter *regexp.Regexp) termSimilarity {
+	similarity := termSimilarity{sentences: make(map[string]map[int]struct{})}
+	number := 0
+	for _, paragraph := range segmentText(content) {
+		for _, sentence := range paragraph.Sentences {
+			for _, word := range wordSplitter.Split(strings.ToLower(sentence.Text), -1) {
+				if word == "" {
+					continue
+				}
+				if similarity.sentences[word] == nil {
+					similarity.sentences[word] = make(map[int]struct{})
+				}
+				similarity.sentences[word][number] = struct{}{}
+			}
+			number++
+		}
+	}
+	return similarity
+}
+
+// returns how alike two terms are from 0 to 1
+func (s termSimilarity) between(a, b string) float64 {
+	return max(stemOverlap(a, b), s.coOccurrence(a, b))
+}
+
+// the share of distinct stemmed words two terms have in common
+func stemOverlap(a, b string) float64 {
+	stemsA := strings.Fields(stemPhrase(a))
+	stemsB := make(map[string]struct{})
+	for _, word := range strings.Fields(stemPhrase(b)) {
+		stemsB[word] = struct{}{}
+	}
+	union := len(stemsB)
+	shared := 0
+	seen := make(map[string]struct{})
+	for _, word := range stemsA {
+		if _, ok := seen[word]; ok {
+			continue
+		}
+		seen[word] = struct{}{}
+		if _, ok := stemsB[word]; ok {
+			shared++
+		} else {
+			union++
+		}
+	}
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+// the Jaccard index of the sets of sentences two terms appear in, a multi word term appears in the sentences
+// holding all of its words
+// unlike a cosine this stays low between a rare term and one found in nearly every sentence
+func (s termSimilarity) coOccurrence(a, b string) float64 {
+	inA, inB := s.termSentences(a), s.termSentences(b)
+	if len(inA) == 0 || len(inB) == 0 {
+		return 0
+	}
+	shared := 0
+	for sentence := range inA {
+		if _, ok := inB[sentence]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(inA)+len(inB)-shared)
+}
+
+// the sentences holding every word of a term
+func (s termSimilarity) termSentences(term string) map[int]struct{} {
+	words := strings.Fields(term)
+	if len(words) == 0 {
+		return nil
+	}
+	found := make(map[int]struct{})
+	for sentence := range s.sentences[words[0]] {
+		inAll := true
+		for _, word := range words[1:] {
+			if _, ok := s.sentences[word][sentence]; !ok {
+				inAll = false
+				break
+			}
+		}
+		if inAll {
+			found[sentence] = struct{}{}
+		}
+	}
+	return found
+}
+
+// selectDiverse picks n keywords from the ranked keywords by Maximal Marginal Relevance, in the order picked
+// content and wordSplitter are used to find which terms share sentences
+func selectDiverse(keywords []Keyword, n int, content string, wordSplitter *regexp.Regexp, opts DiversityOptions) []Keyword {
+	n = max(n, 0)
+	candidates := opts.Candidates
+	if candidates == 0 {
+		candidates = 3 * n
+	}
+	if len(keywords) > candidates {
+		keywords = keywords[:candidates]
+	}
+	if len(keywords) == 0 || keywords[0].Score <= 0 {
+		return keywords[:min(n, len(keywords))]
+	}
+
+	similarity := newTermSimilarity(content, wordSplitter)
+	best := keywords[0].Score
+	// the highest similarity of each remaining candidate to anything picked so far
+	closest := make([]float64, len(keywords))
+	picked := make([]bool, len(keywords))
+
+	selected := make([]Keyword, 0, n)
+	for len(selected) < n && len(selected) < len(keywords) {
+		pick := -1
+		pickScore := math.Inf(-1)
+		for i, keyword := range keywords {
+			if picked[i] {
+				continue
+			}
+			// ties go to the candidate ranked higher, as candidates are visited in rank order
+			score := opts.Lambda*keyword.Score/best - (1-opts.Lambda)*closest[i]
+			if score > pickScore {
+				pick, pickScore = i, score
+			}
+		}
+
+		picked[pick] = true
+		selected = append(selected, keywords[pick])
+		for i := range keywords {
+			if !picked[i] {
+				closest[i] = max(closest[i], similarity.between(keywords[pick].Term, keywords[i].Term))
+			}
+		}
+	}
+	return selected
+}
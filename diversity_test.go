@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+/*
+This file tests for:
+- stem overlap between terms
+- sentence co-occurrence between terms
+- picking diverse keywords by maximal marginal relevance
+- lambda of 1 keeping the ranking by score
+- a negative number of keywords picks none
+- rejecting a lambda outside 0 to 1
+- a lambda of 0 in a config file turns re-ranking on and leaving it out keeps it off
+*/
+
+func TestStemOverlap(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		expected float64
+	}{
+		{"function", "functions", 1},
+		{"type classes", "types", 0.5},
+		{"haskell", "rust", 0},
+	}
+	for _, c := range cases {
+		if overlap := stemOverlap(c.a, c.b); overlap != c.expected {
+			t.Errorf("%s and %s: expected %.2f, got %.2f", c.a, c.b, c.expected, overlap)
+		}
+	}
+}
+
+func TestCoOccurrence(t *testing.T) {
+	similarity := newTermSimilarity("Lazy evaluation uses thunks. Thunks are lazy. Rust is strict.", defaultWordSplitter)
+	if co := similarity.coOccurrence("lazy evaluation", "thunks"); co != 0.5 {
+		t.Errorf("Expected 0.5, got %.2f", co)
+	}
+	if co := similarity.coOccurrence("lazy", "thunks"); co != 1 {
+		t.Errorf("Expected 1, got %.2f", co)
+	}
+	if co := similarity.coOccurrence("rust", "thunks"); co != 0 {
+		t.Errorf("Expected 0, got %.2f", co)
+	}
+}
+
+func TestSelectDiverse(t *testing.T) {
+	content := "Functions are values. Functions compose. A function returns. Monads sequence effects. Types check programs."
+	keywords := []Keyword{
+		{Term: "functions", Score: 0.4},
+		{Term: "function", Score: 0.3},
+		{Term: "monads", Score: 0.2},
+		{Term: "types", Score: 0.2},
+	}
+
+	picked := selectDiverse(keywords, 3, content, defaultWordSplitter, DiversityOptions{Lambda: 0.5})
+	if terms := strings.Join(keywordTerms(picked), ","); terms != "functions,monads,types" {
+		t.Errorf("Expected function to be passed over, got %s", terms)
+	}
+
+	kept := selectDiverse(keywords, 3, content, defaultWordSplitter, DiversityOptions{Lambda: 1})
+	if terms := strings.Join(keywordTerms(kept), ","); terms != "functions,function,monads" {
+		t.Errorf("Expected the ranking by score, got %s", terms)
+	}
+
+	limited := selectDiverse(keywords, 3, content, defaultWordSplitter, DiversityOptions{Lambda: 0.5, Candidates: 2})
+	if len(limited) != 2 {
+		t.Errorf("Expected only the 2 candidates, got %d", len(limited))
+	}
+
+	if none := selectDiverse(keywords, -1, content, defaultWordSplitter, DiversityOptions{Lambda: 0.5}); len(none) != 0 {
+		t.Errorf("Expected no keywords for a negative count, got %d", len(none))
+	}
+}
+
+func TestExtractDiversity(t *testing.T) {
+	content := "Functions and functions and functions. A function. Monads are monads."
+	opts := ExtractOptions{
+		NumKeywords: 2,
+		Stopwords:   map[string]struct{}{"and": {}, "are": {}},
+		Diversity:   &DiversityOptions{Lambda: 0.5},
+	}
+	keywords, err := extractKeywords(content, opts)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if terms := strings.Join(keywordTerms(keywords), ","); terms != "functions,monads" {
+		t.Errorf("Expected functions and monads, got %s", terms)
+	}
+
+	opts.Diversity = &DiversityOptions{Lambda: 1.5}
+	if _, err := extractKeywords(content, opts); err == nil {
+		t.Error("Expected error for a lambda above 1, got nil")
+	}
+
+	lambda := -0.1
+	config := defaultConfig()
+	config.Diversity.Lambda = &lambda
+	if _, err := config.options(); err == nil || !strings.Contains(err.Error(), "diversity.lambda") {
+		t.Errorf("Expected a diversity.lambda error, got: %v", err)
+	}
+}
+
+func TestDiversityConfig(t *testing.T) {
+	for content, expected := range map[string]*DiversityOptions{
+		`{"diversity": {"lambda": 0}}`:     {Lambda: 0},
+		`{"diversity": {"lambda": 0.7}}`:   {Lambda: 0.7},
+		`{"diversity": {"candidates": 5}}`: nil,
+		`{"diversity": {"lambda": null}}`:  nil,
+		`{"keywords": 3}`:                  nil,
+	} {
+		config, err := loadConfig(writeConfigFile(t, "config.json", content))
+		if err != nil {
+			t.Fatalf("%s: expected no error, got: %v", content, err)
+		}
+		opts, err := config.options()
+		if err != nil {
+			t.Fatalf("%s: expected no error, got: %v", content, err)
+		}
+		if (opts.Diversity == nil) != (expected == nil) || (expected != nil && opts.Diversity.Lambda != expected.Lambda) {
+			t.Errorf("%s: expected %+v, got %+v", content, expected, opts.Diversity)
+		}
+	}
+
+	// -diversity 0 asks for re-ranking by variety alone, without it function ranks second
+	input := writeConfigFile(t, "input.txt", "Functions and functions and functions. A function and a function. Monads.")
+	var out bytes.Buffer
+	if err := runExtract([]string{"-n", "2", "-diversity", "0", input}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if out.String() != "functions\nmonads\n" {
+		t.Errorf("Expected functions and monads, got %q", out.String())
+	}
+}
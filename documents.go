@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Document is one piece of text in a dataset or corpus
+// Keywords holds the gold standard keywords when the dataset provides them
+// Time is when the document was written, taken from a JSONL field or the modification time of its file
+type Document struct {
+	ID       string    `json:"id"`
+	Text     string    `json:"text"`
+	Keywords []string  `json:"keywords,omitempty"`
+	Time     time.Time `json:"-"`
+}
+
+// the layouts tried in order when reading a timestamp written as a string
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// loadDocuments reads a dataset from a JSONL file or from a directory
+// directories may use the SemEval/Inspec layout of docsutf8/ and keys/ or hold .txt files beside .key files
+func loadDocuments(path string) ([]Document, error) {
+	return loadTimedDocuments(path, "")
+}
+
+// loadTimedDocuments reads a dataset like loadDocuments, also reading each JSONL document's time from timeField
+// documents read from a directory always take their time from the modification time of their file
+func loadTimedDocuments(path, timeField string) ([]Document, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return loadDirectoryDocuments(path)
+	}
+	return loadJSONLDocuments(path, timeField)
+}
+
+// loadDocumentsOrText reads a JSONL file or directory like loadDocuments, and any other file as a single document
+// named after the file
+func loadDocumentsOrText(path string) ([]Document, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() || strings.EqualFold(filepath.Ext(path), ".jsonl") {
+		return loadDocuments(path)
+	}
+
+	content, err := LoadFileContent(path)
+	if err != nil {
+		return nil, err
+	}
+	return []Document{{ID: filepath.Base(path), Text: content}}, nil
+}
+
+// reads one JSON document per line, documents without an id are numbered by line
+// when timeField is set every document must have a timestamp in that field
+func loadJSONLDocuments(path, timeField string) ([]Document, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.New("Error opening file, check file path: " + err.Error())
+	}
+	defer file.Close()
+
+	var docs []Document
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var doc Document
+		if err := json.Unmarshal(scanner.Bytes(), &doc); err != nil {
+			return nil, errors.New(path + ":" + strconv.Itoa(line) + ": " + err.Error())
+		}
+		if doc.ID == "" {
+			doc.ID = strconv.Itoa(line)
+		}
+		if timeField != "" {
+			var fields map[string]json.RawMessage
+			json.Unmarshal(scanner.Bytes(), &fields)
+			raw, ok := fields[timeField]
+			if !ok {
+				return nil, errors.New(path + ":" + strconv.Itoa(line) + ": missing timestamp field " + timeField)
+			}
+			doc.Time, err = parseTimestamp(raw)
+			if err != nil {
+				return nil, errors.New(path + ":" + strconv.Itoa(line) + ": " + timeField + ": " + err.Error())
+			}
+		}
+		docs = append(docs, doc)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, errors.New("no documents found in " + path)
+	}
+	return docs, nil
+}
+
+// reads the text files in a directory along with any matching key files
+// the SemEval/Inspec layout keeps texts in docsutf8/ and keys in keys/, otherwise both sit side by side
+func loadDirectoryDocuments(dir string) ([]Document, error) {
+	textDir, keyDir := dir, dir
+	if info, err := os.Stat(filepath.Join(dir, "docsutf8")); err == nil && info.IsDir() {
+		textDir, keyDir = filepath.Join(dir, "docsutf8"), filepath.Join(dir, "keys")
+	}
+
+	paths, err := filepath.Glob(filepath.Join(textDir, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	docs := make([]Document, 0, len(paths))
+	for _, path := range paths {
+		content, err := LoadFileContent(path)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		id := strings.TrimSuffix(filepath.Base(path), ".txt")
+		doc := Document{ID: id, Text: content, Time: info.ModTime()}
+
+		// key files are optional so a plain directory of texts can be loaded as a corpus
+		keys, err := LoadFileContent(filepath.Join(keyDir, id+".key"))
+		if err == nil {
+			doc.Keywords = parseKeyFile(keys)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		docs = append(docs, doc)
+	}
+
+	if len(docs) == 0 {
+		return nil, errors.New("no .txt documents found in " + textDir)
+	}
+	return docs, nil
+}
+
+// key files list one keyword per line, some datasets separate them with semicolons instead
+func parseKeyFile(content string) []string {
+	var keywords []string
+	for _, line := range strings.FieldsFunc(content, func(r rune) bool { return r == '\n' || r == ';' }) {
+		if keyword := strings.TrimSpace(line); keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+	return keywords
+}
+
+// reads a timestamp written as a string in one of timestampLayouts or as a number of unix seconds
+func parseTimestamp(raw json.RawMessage) (time.Time, error) {
+	var seconds float64
+	if err := json.Unmarshal(raw, &seconds); err == nil {
+		whole := int64(seconds)
+		return time.Unix(whole, int64((seconds-float64(whole))*1e9)).UTC(), nil
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return time.Time{}, errors.New("expected a date string or unix seconds, got " + string(raw))
+	}
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(text)); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("unrecognised date " + text + ", expected a format such as 2006-01-02 or RFC 3339")
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+/*
+This file tests for:
+- loading JSONL documents with and without ids
+- reporting the line of bad JSON
+- loading the SemEval/Inspec directory layout
+- loading texts with key files beside them, and without keys
+- parsing key files
+- reading timestamps from a JSONL field or file modification times
+*/
+func TestLoadDocuments(t *testing.T) {
+	// Test loading a JSONL dataset
+	t.Run("JSONL", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "docs.jsonl")
+		content := `{"id":"doc1","text":"Haskell is lazy","keywords":["haskell"]}` + "\n\n" + `{"text":"GHC compiles Haskell"}` + "\n"
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+
+		docs, err := loadDocuments(path)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(docs) != 2 {
+			t.Fatalf("Expected 2 documents, got %d", len(docs))
+		}
+		if docs[0].ID != "doc1" || len(docs[0].Keywords) != 1 {
+			t.Errorf("Expected doc1 with 1 keyword, got %+v", docs[0])
+		}
+		// missing ids are numbered by line
+		if docs[1].ID != "3" {
+			t.Errorf("Expected id '3' for the document on line 3, got '%s'", docs[1].ID)
+		}
+	})
+
+	// Test bad JSON reports its line
+	t.Run("BadJSONL", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bad.jsonl")
+		if err := os.WriteFile(path, []byte("{\"text\":\"ok\"}\n{bad\n"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+
+		_, err := loadDocuments(path)
+		if err == nil {
+			t.Fatal("Expected error for bad JSON, got nil")
+		}
+		if want := path + ":2:"; len(err.Error()) < len(want) || err.Error()[:len(want)] != want {
+			t.Errorf("Expected error to start with '%s', got: %v", want, err)
+		}
+	})
+
+	// Test the SemEval/Inspec layout
+	t.Run("InspecLayout", func(t *testing.T) {
+		dir := t.TempDir()
+		os.Mkdir(filepath.Join(dir, "docsutf8"), 0755)
+		os.Mkdir(filepath.Join(dir, "keys"), 0755)
+		os.WriteFile(filepath.Join(dir, "docsutf8", "101.txt"), []byte("Haskell is lazy"), 0644)
+		os.WriteFile(filepath.Join(dir, "keys", "101.key"), []byte("haskell\nlazy evaluation\n"), 0644)
+
+		docs, err := loadDocuments(dir)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(docs) != 1 || docs[0].ID != "101" || len(docs[0].Keywords) != 2 {
+			t.Errorf("Expected document 101 with 2 keywords, got %+v", docs)
+		}
+	})
+
+	// Test a flat directory where only some texts have keys
+	t.Run("FlatDirectory", func(t *testing.T) {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, "a.txt"), []byte("Haskell is lazy"), 0644)
+		os.WriteFile(filepath.Join(dir, "a.key"), []byte("haskell"), 0644)
+		os.WriteFile(filepath.Join(dir, "b.txt"), []byte("GHC compiles Haskell"), 0644)
+
+		docs, err := loadDocuments(dir)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(docs) != 2 || len(docs[0].Keywords) != 1 || len(docs[1].Keywords) != 0 {
+			t.Errorf("Expected a with keys and b without, got %+v", docs)
+		}
+	})
+
+	// Test an empty directory
+	t.Run("EmptyDirectory", func(t *testing.T) {
+		if _, err := loadDocuments(t.TempDir()); err == nil {
+			t.Error("Expected error for empty directory, got nil")
+		}
+	})
+}
+
+func TestLoadTimedDocuments(t *testing.T) {
+	// Test timestamps from a JSONL field
+	t.Run("JSONL", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "docs.jsonl")
+		content := `{"text":"a","date":"2021-05-03"}` + "\n" + `{"text":"b","date":1620000000}` + "\n"
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+
+		docs, err := loadTimedDocuments(path, "date")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if !docs[0].Time.Equal(time.Date(2021, 5, 3, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected 2021-05-03, got %v", docs[0].Time)
+		}
+		if docs[1].Time.Unix() != 1620000000 {
+			t.Errorf("Expected unix time 1620000000, got %d", docs[1].Time.Unix())
+		}
+
+		// a missing field names the line
+		if _, err := loadTimedDocuments(path, "published"); err == nil || !strings.Contains(err.Error(), ":1: missing timestamp field published") {
+			t.Errorf("Expected missing field error on line 1, got: %v", err)
+		}
+	})
+
+	// Test file modification times for directories
+	t.Run("Directory", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "a.txt")
+		if err := os.WriteFile(path, []byte("Haskell is lazy"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		modified := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		os.Chtimes(path, modified, modified)
+
+		docs, err := loadTimedDocuments(dir, "timestamp")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if !docs[0].Time.Equal(modified) {
+			t.Errorf("Expected %v, got %v", modified, docs[0].Time)
+		}
+	})
+}
+
+func TestParseTimestamp(t *testing.T) {
+	valid := map[string]time.Time{
+		`"2021-05-03T10:00:00Z"`:      time.Date(2021, 5, 3, 10, 0, 0, 0, time.UTC),
+		`"2021-05-03T10:00:00+02:00"`: time.Date(2021, 5, 3, 8, 0, 0, 0, time.UTC),
+		`"2021-05-03 10:00:00"`:       time.Date(2021, 5, 3, 10, 0, 0, 0, time.UTC),
+		`"2021-05"`:                   time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC),
+		`0`:                           time.Unix(0, 0),
+	}
+	for raw, expected := range valid {
+		got, err := parseTimestamp(json.RawMessage(raw))
+		if err != nil {
+			t.Errorf("Expected no error for %s, got: %v", raw, err)
+			continue
+		}
+		if !got.Equal(expected) {
+			t.Errorf("Expected %v for %s, got %v", expected, raw, got)
+		}
+	}
+
+	for _, raw := range []string{`"May 3rd"`, `true`, `{}`} {
+		if _, err := parseTimestamp(json.RawMessage(raw)); err == nil {
+			t.Errorf("Expected error for %s, got nil", raw)
+		}
+	}
+}
+
+func TestParseKeyFile(t *testing.T) {
+	keywords := parseKeyFile("type classes\n  lazy evaluation ;monads\n\n")
+	expected := []string{"type classes", "lazy evaluation", "monads"}
+	if len(keywords) != len(expected) {
+		t.Fatalf("Expected %d keywords, got %v", len(expected), keywords)
+	}
+	for i, kw := range expected {
+		if keywords[i] != kw {
+			t.Errorf("Expected '%s', got '%s'", kw, keywords[i])
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// EvalMetrics holds the scores for the top K keywords averaged over every document
+type EvalMetrics struct {
+	K         int     `json:"k"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+	NDCG      float64 `json:"ndcg"`
+}
+
+// EvalReport is the result of running one algorithm over a dataset
+// MAP is computed over the full list of keywords returned, up to the largest K
+type EvalReport struct {
+	Algorithm string        `json:"algorithm"`
+	Documents int           `json:"documents"`
+	Skipped   int           `json:"skipped"`
+	AtK       []EvalMetrics `json:"at_k"`
+	MAP       float64       `json:"map"`
+}
+
+// marks which predicted keywords match a gold keyword, comparing Porter stems of every word
+// each gold keyword can only be matched once so repeated predictions are not rewarded twice
+func matchKeywords(predicted, gold []string) []bool {
+	unmatched := make(map[string]int, len(gold))
+	for _, kw := range gold {
+		unmatched[stemPhrase(kw)]++
+	}
+
+	relevant := make([]bool, len(predicted))
+	for i, kw := range predicted {
+		key := stemPhrase(kw)
+		if unmatched[key] > 0 {
+			unmatched[key]--
+			relevant[i] = true
+		}
+	}
+	return relevant
+}
+
+// precision, recall and F1 of the first k predictions
+// precision divides by the number of predictions made, which can be fewer than k for short documents
+func precisionRecallF1(relevant []bool, numGold, k int) (float64, float64, float64) {
+	top := relevant[:min(k, len(relevant))]
+	hits := 0
+	for _, rel := range top {
+		if rel {
+			hits++
+		}
+	}
+	if hits == 0 {
+		return 0, 0, 0
+	}
+
+	precision := float64(hits) / float64(len(top))
+	recall := float64(hits) / float64(numGold)
+	return precision, recall, 2 * precision * recall / (precision + recall)
+}
+
+// average of the precision at each rank holding a correct keyword, divided by the number of gold keywords
+func averagePrecision(relevant []bool, numGold int) float64 {
+	if numGold == 0 {
+		return 0
+	}
+	hits := 0
+	sum := 0.0
+	for i, rel := range relevant {
+		if rel {
+			hits++
+			sum += float64(hits) / float64(i+1)
+		}
+	}
+	return sum / float64(numGold)
+}
+
+// normalised discounted cumulative gain of the first k predictions with binary relevance
+func ndcg(relevant []bool, numGold, k int) float64 {
+	dcg := 0.0
+	for i, rel := range relevant[:min(k, len(relevant))] {
+		if rel {
+			dcg += 1 / math.Log2(float64(i+2))
+		}
+	}
+
+	ideal := 0.0
+	for i := 0; i < min(k, numGold); i++ {
+		ideal += 1 / math.Log2(float64(i+2))
+	}
+	if ideal == 0 {
+		return 0
+	}
+	return dcg / ideal
+}
+
+// runs the extraction options over every document with gold keywords and averages the metrics
+// documents without gold keywords are skipped, documents with no valid words score zero and any other
+// extraction error is returned
+func evaluateDocuments(docs []Document, opts ExtractOptions, ks []int) (EvalReport, error) {
+	if len(ks) == 0 {
+		return EvalReport{}, errors.New("no values of k given")
+	}
+	maxK := 0
+	for _, k := range ks {
+		if k <= 0 {
+			return EvalReport{}, errors.New("k must be above zero")
+		}
+		maxK = max(maxK, k)
+	}
+
+	// load the stopwords once rather than for every document
+	if opts.Stopwords == nil {
+		stopwords, err := LoadStopwords(defaultStopwordsPath)
+		if err != nil {
+			return EvalReport{}, err
+		}
+		opts.Stopwords = stopwords
+	}
+	opts.NumKeywords = maxK
+
+	report := EvalReport{AtK: make([]EvalMetrics, len(ks))}
+	for i, k := range ks {
+		report.AtK[i].K = k
+	}
+
+	for _, doc := range docs {
+		if len(doc.Keywords) == 0 {
+			report.Skipped++
+			continue
+		}
+		report.Documents++
+
+		keywords, err := extractKeywords(doc.Text, opts)
+		if err != nil && !errors.Is(err, errNoValidWords) {
+			return EvalReport{}, errors.New(doc.ID + ": " + err.Error())
+		}
+		relevant := matchKeywords(keywordTerms(keywords), doc.Keywords)
+
+		for i, k := range ks {
+			p, r, f := precisionRecallF1(relevant, len(doc.Keywords), k)
+			report.AtK[i].Precision += p
+			report.AtK[i].Recall += r
+			report.AtK[i].F1 += f
+			report.AtK[i].NDCG += ndcg(relevant, len(doc.Keywords), k)
+		}
+		report.MAP += averagePrecision(relevant, len(doc.Keywords))
+	}
+
+	if report.Documents == 0 {
+		return EvalReport{}, errors.New("no documents with gold keywords to evaluate")
+	}
+
+	// average over the documents evaluated
+	n := float64(report.Documents)
+	for i := range report.AtK {
+		report.AtK[i].Precision /= n
+		report.AtK[i].Recall /= n
+		report.AtK[i].F1 /= n
+		report.AtK[i].NDCG /= n
+	}
+	report.MAP /= n
+
+	return report, nil
+}
+
+// parses a comma separated list of numbers such as 5,10,15
+func parseIntList(list string) ([]int, error) {
+	var values []int
+	for _, field := range strings.Split(list, ",") {
+		value, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, errors.New("invalid number in list: " + field)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// the columns written for an evaluation report by every format but json and plain
+var evalColumns = []string{"k", "precision", "recall", "f1", "ndcg"}
+
+// writes an evaluation report in one of the formats accepted by newResultWriter
+// plain writes one metric per line, such as "f1@5 0.3429"
+func writeEvalReport(w io.Writer, format string, report EvalReport) error {
+	writer, err := newResultWriter(format)
+	if err != nil {
+		return err
+	}
+
+	score := func(v float64) string { return strconv.FormatFloat(v, 'f', 4, 64) }
+	records := Records{
+		Columns: evalColumns,
+		Value:   report,
+		Header:  []string{fmt.Sprintf("algorithm: %s  documents: %d  skipped: %d  MAP: %.4f", report.Algorithm, report.Documents, report.Skipped, report.MAP)},
+	}
+	for _, m := range report.AtK {
+		k := strconv.Itoa(m.K)
+		records.Rows = append(records.Rows, []string{k, score(m.Precision), score(m.Recall), score(m.F1), score(m.NDCG)})
+		records.Lines = append(records.Lines,
+			"precision@"+k+" "+score(m.Precision), "recall@"+k+" "+score(m.Recall),
+			"f1@"+k+" "+score(m.F1), "ndcg@"+k+" "+score(m.NDCG))
+	}
+	records.Lines = append(records.Lines, "map "+score(report.MAP))
+	return writer.WriteRecords(w, records)
+}
+
+// runEval is the eval command, it scores an algorithm against a dataset of gold keywords
+func runEval(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("eval", flag.ContinueOnError)
+	dataset := flags.String("dataset", "", "JSONL file or SemEval/Inspec directory of documents with gold keywords")
+	configPath := flags.String("config", "", "JSON, YAML or TOML config file")
+	algorithm := flags.String("algorithm", "tf", "algorithm to evaluate: "+strings.Join(algorithmNames(), ", "))
+	kList := flags.String("k", "5,10,15", "comma separated cut-offs to report metrics at")
+	format := flags.String("format", "table", "output format: "+strings.Join(outputFormatNames(), ", "))
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *dataset == "" {
+		return errors.New("eval needs a -dataset")
+	}
+
+	ks, err := parseIntList(*kList)
+	if err != nil {
+		return err
+	}
+	config, err := loadConfigWithFlags(*configPath, flags, map[string]func(*Config){
+		"algorithm": func(c *Config) { c.Algorithm = *algorithm },
+		"format":    func(c *Config) { c.Format = *format },
+	})
+	if err != nil {
+		return err
+	}
+	if config.Format == "" {
+		config.Format = *format
+	}
+	opts, err := config.options()
+	if err != nil {
+		return err
+	}
+	docs, err := loadDocuments(*dataset)
+	if err != nil {
+		return err
+	}
+
+	report, err := evaluateDocuments(docs, opts, ks)
+	if err != nil {
+		return err
+	}
+	report.Algorithm = config.Algorithm
+
+	return writeEvalReport(stdout, config.Format, report)
+}
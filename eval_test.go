@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/*
+This file tests for:
+- stem aware keyword matching, each gold keyword matched once
+- precision, recall and F1 at k
+- average precision
+- nDCG at k
+- evaluating a dataset and skipping documents without gold keywords
+- documents with no valid words score zero, other extraction errors are returned
+- the eval command end to end in every output format
+*/
+func TestMatchKeywords(t *testing.T) {
+	relevant := matchKeywords(
+		[]string{"functions", "type class", "function", "monad"},
+		[]string{"function", "Type Classes"},
+	)
+
+	expected := []bool{true, true, false, false}
+	for i, rel := range expected {
+		if relevant[i] != rel {
+			t.Errorf("Expected relevance %v at rank %d, got %v", rel, i+1, relevant[i])
+		}
+	}
+}
+
+func TestEvalMetrics(t *testing.T) {
+	// hits at ranks 1 and 3 out of 4 gold keywords
+	relevant := []bool{true, false, true, false, false}
+
+	// Test precision, recall and F1
+	t.Run("PrecisionRecallF1", func(t *testing.T) {
+		p, r, f := precisionRecallF1(relevant, 4, 3)
+		if math.Abs(p-2.0/3.0) > 0.0001 || math.Abs(r-0.5) > 0.0001 {
+			t.Errorf("Expected P 0.6667 and R 0.5, got %.4f and %.4f", p, r)
+		}
+		expectedF := 2 * (2.0 / 3.0) * 0.5 / (2.0/3.0 + 0.5)
+		if math.Abs(f-expectedF) > 0.0001 {
+			t.Errorf("Expected F1 %.4f, got %.4f", expectedF, f)
+		}
+
+		// k beyond the number of predictions divides by the predictions made
+		p, _, _ = precisionRecallF1(relevant, 4, 10)
+		if math.Abs(p-0.4) > 0.0001 {
+			t.Errorf("Expected P 0.4, got %.4f", p)
+		}
+
+		if p, r, f := precisionRecallF1([]bool{false}, 1, 1); p != 0 || r != 0 || f != 0 {
+			t.Error("Expected all zero with no hits")
+		}
+	})
+
+	// Test average precision
+	t.Run("AveragePrecision", func(t *testing.T) {
+		// (1/1 + 2/3) / 4
+		expected := (1 + 2.0/3.0) / 4
+		if ap := averagePrecision(relevant, 4); math.Abs(ap-expected) > 0.0001 {
+			t.Errorf("Expected AP %.4f, got %.4f", expected, ap)
+		}
+	})
+
+	// Test nDCG
+	t.Run("NDCG", func(t *testing.T) {
+		// DCG = 1 + 1/log2(4), ideal = 1 + 1/log2(3) + 1/log2(4)
+		expected := (1 + 0.5) / (1 + 1/math.Log2(3) + 0.5)
+		if got := ndcg(relevant, 4, 3); math.Abs(got-expected) > 0.0001 {
+			t.Errorf("Expected nDCG %.4f, got %.4f", expected, got)
+		}
+		if got := ndcg([]bool{true, true}, 2, 2); math.Abs(got-1) > 0.0001 {
+			t.Errorf("Expected perfect nDCG 1, got %.4f", got)
+		}
+	})
+}
+
+func TestEvaluateDocuments(t *testing.T) {
+	docs := []Document{
+		{ID: "1", Text: "Haskell haskell haskell compiler compiler", Keywords: []string{"haskell"}},
+		{ID: "2", Text: "Monads monads functions", Keywords: []string{"monad", "function"}},
+		{ID: "3", Text: "No gold keywords here"},
+	}
+	opts := ExtractOptions{Stopwords: map[string]struct{}{}}
+
+	report, err := evaluateDocuments(docs, opts, []int{1, 2})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if report.Documents != 2 || report.Skipped != 1 {
+		t.Errorf("Expected 2 documents and 1 skipped, got %d and %d", report.Documents, report.Skipped)
+	}
+	// both documents get their top keyword right
+	if math.Abs(report.AtK[0].Precision-1) > 0.0001 {
+		t.Errorf("Expected P@1 of 1, got %.4f", report.AtK[0].Precision)
+	}
+	// doc 1 recall 1, doc 2 recall 1 at k=2
+	if math.Abs(report.AtK[1].Recall-1) > 0.0001 {
+		t.Errorf("Expected R@2 of 1, got %.4f", report.AtK[1].Recall)
+	}
+	if math.Abs(report.MAP-1) > 0.0001 {
+		t.Errorf("Expected MAP of 1, got %.4f", report.MAP)
+	}
+
+	if _, err := evaluateDocuments(docs[2:], opts, []int{1}); err == nil {
+		t.Error("Expected error with no gold keywords, got nil")
+	}
+	if _, err := evaluateDocuments(docs, opts, []int{0}); err == nil {
+		t.Error("Expected error for k of 0, got nil")
+	}
+
+	// Test a document with no valid words scores zero but is still counted
+	t.Run("NoValidWords", func(t *testing.T) {
+		empty := append([]Document{{ID: "4", Text: "a an of", Keywords: []string{"haskell"}}}, docs...)
+		report, err := evaluateDocuments(empty, opts, []int{1})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if report.Documents != 3 || math.Abs(report.AtK[0].Precision-2.0/3) > 0.0001 {
+			t.Errorf("Expected 3 documents with P@1 of 0.6667, got %d and %.4f", report.Documents, report.AtK[0].Precision)
+		}
+	})
+
+	// Test other extraction errors are returned rather than scored as zero
+	t.Run("Errors", func(t *testing.T) {
+		broken := opts
+		broken.Snippets = &SnippetOptions{Window: -1}
+		if _, err := evaluateDocuments(docs, broken, []int{1}); err == nil || !strings.HasPrefix(err.Error(), "1: ") {
+			t.Errorf("Expected an error naming document 1, got: %v", err)
+		}
+	})
+}
+
+func TestRunEval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dataset.jsonl")
+	content := `{"id":"a","text":"Haskell is a lazy language. Haskell has lazy evaluation.","keywords":["haskell","lazy evaluation"]}` + "\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := runEval([]string{"-dataset", path, "-k", "1,3", "-algorithm", "collocation"}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(out.String(), "algorithm: collocation") || !strings.Contains(out.String(), "precision") {
+		t.Errorf("Expected a report table, got:\n%s", out.String())
+	}
+
+	// Test the report is written through the other output formats
+	out.Reset()
+	if err := runEval([]string{"-dataset", path, "-k", "1", "-format", "plain"}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "precision@1 1.0000\n") || !strings.HasSuffix(out.String(), "map 0.5000\n") {
+		t.Errorf("Expected one metric per line, got:\n%s", out.String())
+	}
+	out.Reset()
+	config := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(config, []byte(`{"format": "json"}`), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := runEval([]string{"-dataset", path, "-k", "1", "-config", config}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(out.String(), `"at_k": [`) {
+		t.Errorf("Expected the config's json format, got:\n%s", out.String())
+	}
+
+	if err := runEval([]string{"-dataset", path, "-algorithm", "bogus"}, &out); err == nil {
+		t.Error("Expected error for unknown algorithm, got nil")
+	}
+	if err := runEval([]string{}, &out); err == nil {
+		t.Error("Expected error without a dataset, got nil")
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// runExtract is the extract command, it prints the keywords of a file, or stdin when no file is given
+// settings come from -config with any flags given overriding it, and -write saves the keywords of a file
+// to its sidecar or front matter instead of printing them
+func runExtract(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("extract", flag.ContinueOnError)
+	configPath := flags.String("config", "", "JSON, YAML or TOML config file")
+	algorithm := flags.String("algorithm", "tf", "algorithm to use: "+strings.Join(algorithmNames(), ", "))
+	numKeywords := flags.Int("n", 10, "number of keywords to print")
+	minLength := flags.Int("min-length", defaultMinWordLength, "minimum word length in characters")
+	format := flags.String("format", "plain", "output format: "+strings.Join(outputFormatNames(), ", "))
+	cacheDir := flags.String("cache", "", "directory caching results by text and config, so unchanged text is not extracted again")
+	cacheStats := flags.Bool("cache-stats", false, "print cache hits and misses to stderr")
+	vocabulary := flags.String("vocab", "", "SKOS RDF/XML or CSV vocabulary to map keywords onto")
+	vocabularyOnly := flags.Bool("vocab-only", false, "only keep keywords found in the vocabulary")
+	synonyms := flags.String("synonyms", "", "comma separated synonym files whose equivalent terms are counted as one")
+	acronyms := flags.Bool("acronyms", false, "count acronyms defined in the text, like \"Long Form (LF)\", as their long form")
+	diversity := flags.Float64("diversity", 0, "re-rank by maximal marginal relevance with this lambda between 0 and 1, lower favours variety, off unless given")
+	selection := flags.String("select", "", "choose how many keywords to print instead of -n: threshold:SCORE, percent:P, length:WORDS or elbow")
+	sentences := flags.Bool("sentences", false, "list the sentences each keyword occurs in, shown by the json format")
+	output := flags.String("write", "", "write the keywords to the file's "+sidecarOutput+" or "+frontMatterOutput+" instead of printing them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return errors.New("extract takes at most one file")
+	}
+	if *output != "" && flags.NArg() == 0 {
+		return errors.New("-write needs a file to write beside")
+	}
+
+	var parsed SelectionOptions
+	if *selection != "" {
+		var err error
+		if parsed, err = parseSelection(*selection); err != nil {
+			return errors.New("-select: " + err.Error())
+		}
+	}
+
+	config, err := loadConfigWithFlags(*configPath, flags, map[string]func(*Config){
+		"algorithm":  func(c *Config) { c.Algorithm = *algorithm },
+		"n":          func(c *Config) { c.NumKeywords = *numKeywords },
+		"min-length": func(c *Config) { c.Filters.MinLength = *minLength },
+		"format":     func(c *Config) { c.Format = *format },
+		"vocab":      func(c *Config) { c.Vocabulary.Path = *vocabulary },
+		"vocab-only": func(c *Config) { c.Vocabulary.Restrict = *vocabularyOnly },
+		"synonyms":   func(c *Config) { c.Synonyms.Files = parseNameList(*synonyms) },
+		"acronyms":   func(c *Config) { c.Synonyms.Acronyms = *acronyms },
+		"diversity":  func(c *Config) { c.Diversity.Lambda = diversity },
+		"sentences":  func(c *Config) { c.Sentences = *sentences },
+		"select": func(c *Config) {
+			// bounds set in the config file still apply
+			parsed.Min, parsed.Max = c.Selection.Min, c.Selection.Max
+			c.Selection = parsed
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := config.options(); err != nil {
+		return err
+	}
+	if config.Format == "" {
+		config.Format = "plain"
+	}
+	writer, err := newResultWriter(config.Format)
+	if err != nil {
+		return err
+	}
+
+	// with no -cache directory the cache only lives for this run, so it always misses
+	cache, err := newKeywordCache(1, *cacheDir)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if *cacheStats {
+			stats := cache.Stats()
+			fmt.Fprintf(os.Stderr, "cache: %d hits, %d misses\n", stats.Hits, stats.Misses)
+		}
+	}()
+
+	if *output != "" {
+		path := flags.Arg(0)
+		changed, err := updateKeywordMetadata(path, *output, func(content string) ([]Keyword, error) {
+			return cache.Extract(content, config)
+		})
+		if err != nil {
+			return err
+		}
+		if *output == sidecarOutput {
+			path = sidecarPath(path)
+		}
+		if changed {
+			fmt.Fprintln(stdout, "updated", path)
+		} else {
+			fmt.Fprintln(stdout, "unchanged", path)
+		}
+		return nil
+	}
+
+	var content string
+	if flags.NArg() == 1 {
+		content, err = LoadFileContent(flags.Arg(0))
+	} else {
+		var data []byte
+		data, err = io.ReadAll(os.Stdin)
+		content = string(data)
+	}
+	if err != nil {
+		return err
+	}
+
+	keywords, err := cache.Extract(content, config)
+	if err != nil {
+		return err
+	}
+	return writer.WriteKeywords(stdout, keywords)
+}
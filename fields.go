@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Field is one named part of a structured document such as its title, abstract or body
+// Weight multiplies the counts of every term found in the field
+type Field struct {
+	Name    string
+	Content string
+	Weight  float64
+}
+
+// FieldKeyword is a keyword along with the names of the fields it was found in
+type FieldKeyword struct {
+	Keyword
+	Fields []string
+}
+
+// finds keywords across the fields of a structured document
+// terms in heavily weighted fields such as a title rank above terms that only appear in the body
+func getFieldKeywords(fields []Field, numKeywords int) ([]FieldKeyword, error) {
+	if numKeywords < 0 {
+		return nil, errors.New("number of keywords must not be negative")
+	}
+
+	stopwords, err := LoadStopwords(defaultStopwordsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	wfi, tci, sources, err := getFieldWordFrequency(fields, stopwords, defaultWordSplitter)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := rankKeywords(wfi, tci)
+	if len(ranked) > numKeywords {
+		ranked = ranked[:numKeywords]
+	}
+
+	keywords := make([]FieldKeyword, 0, len(ranked))
+	for _, kw := range ranked {
+		keywords = append(keywords, FieldKeyword{Keyword: kw, Fields: sources[kw.Term]})
+	}
+
+	return keywords, nil
+}
+
+// Combines the TermCountIndex of each field into one weighted frequency index
+// Returns the weighted frequencies, the unweighted combined counts and which fields each term came from
+func getFieldWordFrequency(fields []Field, stopwords map[string]struct{}, wordSplitter *regexp.Regexp) (TermFrequencyIndex, TermCountIndex, map[string][]string, error) {
+	if len(fields) == 0 {
+		return nil, nil, nil, errors.New("no fields given")
+	}
+
+	weighted := make(map[string]float64)
+	tci := make(TermCountIndex)
+	sources := make(map[string][]string)
+	seen := make(map[string]struct{})
+	totalWeight := 0.0
+
+	for _, field := range fields {
+		// check the field is usable
+		if field.Weight <= 0 {
+			return nil, nil, nil, errors.New("field " + field.Name + " must have a weight above zero")
+		}
+		if _, dup := seen[field.Name]; dup {
+			return nil, nil, nil, errors.New("duplicate field: " + field.Name)
+		}
+		seen[field.Name] = struct{}{}
+
+		content := defaultFilterChain().Apply(field.Content)
+
+		// a field with no valid words, such as an empty abstract, just contributes nothing
+		fieldCount, err := getWordCount(content, stopwords, wordSplitter)
+		if err != nil {
+			continue
+		}
+
+		// every word in the field adds to the weighted total so heavy fields do not dilute each other
+		totalWeight += field.Weight * float64(len(wordSplitter.Split(strings.ToLower(content), -1)))
+
+		for word, count := range fieldCount {
+			weighted[word] += field.Weight * float64(count)
+			tci[word] += count
+			sources[word] = append(sources[word], field.Name)
+		}
+	}
+
+	// handle no valid words in any field
+	if len(tci) == 0 {
+		return nil, nil, nil, errors.New("no valid words found in any field")
+	}
+
+	// divide the weighted counts by the weighted number of words
+	wfi := make(TermFrequencyIndex, len(weighted))
+	for word, score := range weighted {
+		wfi[word] = score / totalWeight
+	}
+
+	return wfi, tci, sources, nil
+}
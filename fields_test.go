@@ -0,0 +1,112 @@
+package main
+
+import (
+	"math"
+	"regexp"
+	"testing"
+)
+
+/*
+This file tests for:
+- terms in heavier fields outscore terms in lighter fields
+- combined counts and source fields are reported
+- fields without valid words are skipped
+- invalid weights, duplicate names and empty input return errors
+- keywords from the default stopwords list
+- a negative number of keywords returns an error
+*/
+func TestGetFieldWordFrequency(t *testing.T) {
+	stopwords := map[string]struct{}{
+		"the": {},
+		"and": {},
+		"of":  {},
+	}
+	wordSplitter := regexp.MustCompile(`\W+`)
+
+	// Test that a term in the title outscores an equally frequent term in the body
+	t.Run("WeightedFields", func(t *testing.T) {
+		fields := []Field{
+			{Name: "title", Content: "haskell", Weight: 3},
+			{Name: "body", Content: "compiler", Weight: 1},
+		}
+
+		wfi, _, _, err := getFieldWordFrequency(fields, stopwords, wordSplitter)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		// total weighted words = 3*1 + 1*1 = 4
+		if math.Abs(wfi["haskell"]-0.75) > 0.0001 {
+			t.Errorf("Expected frequency 0.75 for 'haskell', got %.4f", wfi["haskell"])
+		}
+		if math.Abs(wfi["compiler"]-0.25) > 0.0001 {
+			t.Errorf("Expected frequency 0.25 for 'compiler', got %.4f", wfi["compiler"])
+		}
+	})
+
+	// Test counts are combined and sources recorded in field order
+	t.Run("CountsAndSources", func(t *testing.T) {
+		fields := []Field{
+			{Name: "title", Content: "Haskell compiler", Weight: 2},
+			{Name: "abstract", Content: "", Weight: 1.5},
+			{Name: "body", Content: "the haskell compiler and haskell runtime", Weight: 1},
+		}
+
+		_, tci, sources, err := getFieldWordFrequency(fields, stopwords, wordSplitter)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if tci["haskell"] != 3 {
+			t.Errorf("Expected combined count 3 for 'haskell', got %d", tci["haskell"])
+		}
+		if got := sources["haskell"]; len(got) != 2 || got[0] != "title" || got[1] != "body" {
+			t.Errorf("Expected sources [title body] for 'haskell', got %v", got)
+		}
+		if got := sources["runtime"]; len(got) != 1 || got[0] != "body" {
+			t.Errorf("Expected sources [body] for 'runtime', got %v", got)
+		}
+	})
+
+	// Test bad input is rejected
+	t.Run("InvalidFields", func(t *testing.T) {
+		cases := map[string][]Field{
+			"NoFields":      nil,
+			"ZeroWeight":    {{Name: "title", Content: "haskell", Weight: 0}},
+			"DuplicateName": {{Name: "body", Content: "haskell", Weight: 1}, {Name: "body", Content: "compiler", Weight: 1}},
+			"NoValidWords":  {{Name: "body", Content: "the and of", Weight: 1}},
+		}
+		for name, fields := range cases {
+			if _, _, _, err := getFieldWordFrequency(fields, stopwords, wordSplitter); err == nil {
+				t.Errorf("%s: expected error, got nil", name)
+			}
+		}
+	})
+}
+
+func TestGetFieldKeywords(t *testing.T) {
+	fields := []Field{
+		{Name: "title", Content: "Glasgow Haskell Compiler", Weight: 5},
+		{Name: "body", Content: "The compiler supports lazy evaluation. Lazy evaluation is the default.", Weight: 1},
+	}
+
+	keywords, err := getFieldKeywords(fields, 3)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(keywords) != 3 {
+		t.Fatalf("Expected 3 keywords, got %d", len(keywords))
+	}
+
+	// compiler appears in the heavily weighted title and in the body
+	if keywords[0].Term != "compiler" {
+		t.Errorf("Expected 'compiler' to rank first, got %q", keywords[0].Term)
+	}
+	if len(keywords[0].Fields) != 2 {
+		t.Errorf("Expected 'compiler' to come from 2 fields, got %v", keywords[0].Fields)
+	}
+
+	if _, err := getFieldKeywords(fields, -1); err == nil {
+		t.Error("Expected error for a negative number of keywords, got nil")
+	}
+}
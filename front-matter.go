@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// the front matter field keywords are written to
+const frontMatterField = "keywords"
+
+// terms matching this can be written as plain YAML scalars, anything else is quoted
+var plainYAMLTerm = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_ -]*[A-Za-z0-9_]$|^[A-Za-z]$`)
+
+// splits Markdown into the lines of its YAML front matter, between --- lines at the very start, and the body
+// ok is false when there is no front matter, in which case body is all of content
+func splitFrontMatter(content string) (lines []string, body string, ok bool) {
+	if !strings.HasPrefix(content, "---\n") && !strings.HasPrefix(content, "---\r\n") {
+		return nil, content, false
+	}
+	rest := content[strings.IndexByte(content, '\n')+1:]
+	for offset := 0; offset < len(rest); {
+		end := strings.IndexByte(rest[offset:], '\n')
+		next := len(rest)
+		if end != -1 {
+			next = offset + end + 1
+		}
+		line := strings.TrimRight(rest[offset:next], "\r\n")
+		// the front matter ends with --- or the YAML document end marker
+		if line == "---" || line == "..." {
+			if offset > 0 {
+				lines = strings.Split(strings.TrimRight(rest[:offset], "\r\n"), "\n")
+			}
+			return lines, rest[next:], true
+		}
+		offset = next
+	}
+	return nil, content, false
+}
+
+// quotes a term when YAML would otherwise read it as something other than the same string
+func yamlString(term string) string {
+	switch strings.ToLower(term) {
+	case "true", "false", "yes", "no", "on", "off", "null":
+		return strconv.Quote(term)
+	}
+	if plainYAMLTerm.MatchString(term) {
+		return term
+	}
+	return strconv.Quote(term)
+}
+
+// injectFrontMatter sets the keywords field of Markdown's front matter to terms, adding front matter if there
+// is none, every other line is kept as it was and an existing keywords field is replaced where it stands
+// injecting the same terms twice gives back the same text
+func injectFrontMatter(content string, terms []string) string {
+	field := []string{frontMatterField + ":"}
+	if len(terms) == 0 {
+		field[0] += " []"
+	}
+	for _, term := range terms {
+		field = append(field, "  - "+yamlString(term))
+	}
+
+	lines, body, ok := splitFrontMatter(content)
+	if !ok {
+		return "---\n" + strings.Join(field, "\n") + "\n---\n" + content
+	}
+	newline := "\n"
+	if strings.HasPrefix(content, "---\r\n") {
+		newline = "\r\n"
+	}
+	for i := range lines {
+		lines[i] = strings.TrimSuffix(lines[i], "\r")
+	}
+
+	var updated []string
+	replaced := false
+	for i := 0; i < len(lines); i++ {
+		if !isFrontMatterField(lines[i]) {
+			updated = append(updated, lines[i])
+			continue
+		}
+		// the old value runs on over indented lines and list items
+		for i+1 < len(lines) && continuesValue(lines[i+1:]) {
+			i++
+		}
+		if !replaced {
+			updated = append(updated, field...)
+			replaced = true
+		}
+	}
+	if !replaced {
+		updated = append(updated, field...)
+	}
+	return "---" + newline + strings.Join(updated, newline) + newline + "---" + newline + body
+}
+
+// reports whether a front matter line starts the top level keywords field
+func isFrontMatterField(line string) bool {
+	if line == "" || line[0] == ' ' || line[0] == '\t' {
+		return false
+	}
+	// the key may be quoted, and is followed by a colon and then a space or the end of the line
+	for _, key := range []string{frontMatterField, `"` + frontMatterField + `"`, "'" + frontMatterField + "'"} {
+		if rest, ok := strings.CutPrefix(line, key); ok {
+			rest = strings.TrimLeft(rest, " \t")
+			if rest == ":" || strings.HasPrefix(rest, ": ") || strings.HasPrefix(rest, ":\t") || strings.HasPrefix(rest, ":\r") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// reports whether lines carry on the value of the field above them, blank lines count when more of it follows
+func continuesValue(lines []string) bool {
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		return strings.HasPrefix(line, " ") || strings.HasPrefix(line, "-")
+	}
+	return false
+}
+
+// writes keywords into the front matter of the Markdown file at path, which holds content,
+// leaving the file alone if they are already there and reporting whether it was changed
+func writeFrontMatter(path, content string, terms []string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	updated := injectFrontMatter(content, terms)
+	if updated == content {
+		return false, nil
+	}
+	return true, writeFileAtomic(path, []byte(updated), info.Mode().Perm())
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+/*
+This file tests for:
+- splitting front matter from the body
+- adding front matter to Markdown without any
+- replacing an existing keywords field in place, quoted or not, and keeping other fields
+- giving back the same text when injecting the same keywords again
+- quoting terms YAML would read as something else
+*/
+
+func TestSplitFrontMatter(t *testing.T) {
+	lines, body, ok := splitFrontMatter("---\ntitle: Notes\ntags: [a]\n---\n# Notes\n")
+	if !ok || !reflect.DeepEqual(lines, []string{"title: Notes", "tags: [a]"}) || body != "# Notes\n" {
+		t.Errorf("Unexpected split: %v %q %v", lines, body, ok)
+	}
+
+	if _, body, ok := splitFrontMatter("# Notes\n---\n"); ok || body != "# Notes\n---\n" {
+		t.Errorf("Expected no front matter, got %q %v", body, ok)
+	}
+	if _, _, ok := splitFrontMatter("---\ntitle: never closed\n"); ok {
+		t.Error("Expected unclosed front matter to be treated as none")
+	}
+	if lines, body, ok := splitFrontMatter("---\n---\nbody"); !ok || len(lines) != 0 || body != "body" {
+		t.Errorf("Expected empty front matter, got %v %q %v", lines, body, ok)
+	}
+}
+
+func TestInjectFrontMatter(t *testing.T) {
+	cases := []struct {
+		name, content, expected string
+		terms                   []string
+	}{
+		{
+			name:     "none",
+			content:  "# Notes\n",
+			terms:    []string{"haskell", "type classes"},
+			expected: "---\nkeywords:\n  - haskell\n  - type classes\n---\n# Notes\n",
+		},
+		{
+			name:     "append",
+			content:  "---\ntitle: Notes\n---\nbody\n",
+			terms:    []string{"haskell"},
+			expected: "---\ntitle: Notes\nkeywords:\n  - haskell\n---\nbody\n",
+		},
+		{
+			name:     "replace list",
+			content:  "---\ntitle: Notes\nkeywords:\n  - old\n\n  - older\ndate: 2024-01-01\n---\nbody\n",
+			terms:    []string{"haskell"},
+			expected: "---\ntitle: Notes\nkeywords:\n  - haskell\ndate: 2024-01-01\n---\nbody\n",
+		},
+		{
+			name:     "replace flow",
+			content:  "---\nkeywords: [old, older]\nnested:\n  keywords: kept\n---\nbody",
+			terms:    nil,
+			expected: "---\nkeywords: []\nnested:\n  keywords: kept\n---\nbody",
+		},
+		{
+			name:     "replace quoted key",
+			content:  "---\n\"keywords\": [old]\nkeywordsx: kept\n---\nbody",
+			terms:    []string{"haskell"},
+			expected: "---\nkeywords:\n  - haskell\nkeywordsx: kept\n---\nbody",
+		},
+		{
+			name:     "crlf",
+			content:  "---\r\ntitle: Notes\r\n---\r\nbody\r\n",
+			terms:    []string{"haskell"},
+			expected: "---\r\ntitle: Notes\r\nkeywords:\r\n  - haskell\r\n---\r\nbody\r\n",
+		},
+		{
+			name:     "quoting",
+			content:  "",
+			terms:    []string{"yes", "2010", "c++", "null"},
+			expected: "---\nkeywords:\n  - \"yes\"\n  - \"2010\"\n  - \"c++\"\n  - \"null\"\n---\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			injected := injectFrontMatter(c.content, c.terms)
+			if injected != c.expected {
+				t.Errorf("Expected:\n%q\ngot:\n%q", c.expected, injected)
+			}
+			if again := injectFrontMatter(injected, c.terms); again != injected {
+				t.Errorf("Expected injecting again to change nothing, got:\n%q", again)
+			}
+		})
+	}
+}
@@ -1,32 +1,205 @@
 package main
 
 import (
+	"errors"
 	"os"
 	"regexp"
+	"strings"
 )
 
+// path to the stopwords list that ships with the repo
+const defaultStopwordsPath = "./data/stopwords.txt"
+
+// splits text on anything that is not a letter or digit in any script, so "Straße" stays one word
+var defaultWordSplitter = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// ExtractOptions controls how keywords are pulled out of a piece of text
+// the zero value of each field falls back to the defaults used by getStringKeywords
+type ExtractOptions struct {
+	NumKeywords   int
+	MinWordLength int                 // 0 uses defaultMinWordLength, ignored when TokenFilters is set
+	TokenFilters  *TokenFilterChain   // nil builds the default chain from Stopwords and MinWordLength
+	Stopwords     map[string]struct{} // nil loads the default stopwords file
+	WordSplitter  *regexp.Regexp      // nil uses defaultWordSplitter
+	Filters       FilterChain         // nil uses defaultFilterChain
+	Position      *PositionWeighting  // nil turns positional weighting off
+	Snippets      *SnippetOptions     // nil skips keyword-in-context snippets
+	Sentences     bool                // true lists the sentences each keyword occurs in
+	Collocations  *CollocationOptions // nil only ranks single words
+	POSPattern    *POSPattern         // nil keeps every part of speech
+	Vocabulary    *VocabularyOptions  // nil keeps terms as they were extracted
+	Synonyms      *SynonymOptions     // nil counts every term on its own
+	Diversity     *DiversityOptions   // nil ranks by score alone
+	Selection     *SelectionOptions   // nil keeps NumKeywords keywords
+}
+
 // finds keywwords for text in a string
 func getStringKeywords(content string, numKeywords int) []string {
-	// parse text
-	stopwords, err := LoadStopwords("./data/stopwords.txt")
+	keywords, err := extractKeywords(content, ExtractOptions{NumKeywords: numKeywords})
 	if err != nil {
-		print("Error loading stopwords:", err)
+		print("Error extracting keywords:", err)
+		return nil
 	}
-	wordSplitter := regexp.MustCompile(`[^a-zA-Z0-9]+`)
 
-	// get word count and frequency
-	wordCount, err := getWordCount(content, stopwords, wordSplitter)
+	return keywordTerms(keywords)
+}
+
+// fills in the default for every field of opts that was left unset
+// options that already have their defaults are returned unchanged, so callers can do this once for many documents
+func (opts ExtractOptions) withDefaults() (ExtractOptions, error) {
+	if opts.Snippets != nil && (opts.Snippets.Window < 0 || opts.Snippets.Max < 0) {
+		return ExtractOptions{}, errors.New("snippet window and max must not be negative")
+	}
+	if opts.Stopwords == nil {
+		loaded, err := LoadStopwords(defaultStopwordsPath)
+		if err != nil {
+			return ExtractOptions{}, err
+		}
+		opts.Stopwords = loaded
+	}
+	if opts.WordSplitter == nil {
+		opts.WordSplitter = defaultWordSplitter
+	}
+	if opts.Filters == nil {
+		opts.Filters = defaultFilterChain()
+	}
+	if opts.TokenFilters == nil {
+		minLength := opts.MinWordLength
+		if minLength == 0 {
+			minLength = defaultMinWordLength
+		}
+		chain := defaultTokenFilterChain(opts.Stopwords, minLength)
+		opts.TokenFilters = &chain
+	}
+	return opts, nil
+}
+
+// countTerms counts the words of content that extractKeywords would go on to score
+func countTerms(content string, opts ExtractOptions) (TermCountIndex, error) {
+	opts, err := opts.withDefaults()
 	if err != nil {
-		print("Error getting word count:", err)
-		return nil
+		return nil, err
+	}
+	return getTokenCount(tokenize(opts.Filters.Apply(content), opts.WordSplitter), *opts.TokenFilters)
+}
+
+// runs the full extraction pipeline over content and returns the top keywords with their scores
+func extractKeywords(content string, opts ExtractOptions) ([]Keyword, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	wordSplitter := opts.WordSplitter
+	if opts.Diversity != nil && (opts.Diversity.Lambda < 0 || opts.Diversity.Lambda > 1 || opts.Diversity.Candidates < 0) {
+		return nil, errors.New("diversity lambda must be between 0 and 1 and candidates must not be negative")
+	}
+
+	// acronyms are defined in brackets, which the default filters remove
+	var synonyms map[string]string
+	if opts.Synonyms != nil {
+		synonyms = opts.Synonyms.dictionary(content)
+	}
+
+	// strip citation markers, links and other markup noise before splitting into words
+	// the filters keep the length of the text, so snippets and sentences are cut from the original at the tokens' offsets
+	original := content
+	content = opts.Filters.Apply(content)
+	if len(content) != len(original) {
+		return nil, errors.New("text filters must keep the length of the text")
+	}
+
+	// get word count and frequency, the tokens are kept for building snippets
+	tokens := tokenize(content, wordSplitter)
+	wordCount, err := getTokenCount(tokens, *opts.TokenFilters)
+	if err != nil {
+		return nil, err
 	}
 	wordFrequency := getWordFrequency(content, wordSplitter, wordCount)
 
+	// only keep words that were counted, getWordFrequency also scores stopwords at zero
+	for word := range wordFrequency {
+		if _, ok := wordCount[word]; !ok {
+			delete(wordFrequency, word)
+		}
+	}
+
+	// treat words that belong together, such as "type classes", as single terms
+	totalWords := len(wordSplitter.Split(strings.ToLower(content), -1))
+	if opts.Collocations != nil {
+		collocations, err := detectCollocations(content, tokens, wordCount, *opts.Collocations)
+		if err != nil {
+			return nil, err
+		}
+		promoteCollocations(wordFrequency, wordCount, collocations, totalWords)
+	}
+	// and so are the long forms of synonyms, such as "glasgow haskell compiler"
+	if synonyms != nil {
+		promoteSynonymPhrases(wordFrequency, wordCount, tokens, synonyms, totalWords)
+	}
+
+	// only keep terms that are part of phrases matching the part-of-speech pattern
+	if opts.POSPattern != nil {
+		filterPOSCandidates(wordFrequency, posCandidateWords(content, opts.POSPattern, wordSplitter))
+	}
+
+	// weight terms by where they appear in the document
+	if opts.Position != nil {
+		wordFrequency = applyPositionWeights(content, wordSplitter, wordFrequency, *opts.Position)
+	}
+
+	// count equivalent terms such as "ghc" and "glasgow haskell compiler" as one
+	if synonyms != nil {
+		mergeSynonyms(wordFrequency, wordCount, synonyms)
+	}
+
 	// get keywords based on frquency of words that are not stopwords
-	keywords := getKeywords(wordFrequency, numKeywords)
+	keywords := rankKeywords(wordFrequency, wordCount)
 
-	return keywords
+	// write terms as the concepts of a controlled vocabulary, before cutting so dropped terms make room
+	if opts.Vocabulary != nil {
+		keywords = opts.Vocabulary.Map(keywords)
+	}
+	// let the scores or the length of the document decide how many keywords to keep
+	numKeywords := opts.NumKeywords
+	if opts.Selection != nil {
+		numKeywords, err = opts.Selection.count(keywords, len(tokens))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Diversity != nil {
+		// pick terms that are not near duplicates of terms already picked
+		keywords = selectDiverse(keywords, numKeywords, content, wordSplitter, *opts.Diversity)
+	} else if len(keywords) > numKeywords {
+		keywords = keywords[:numKeywords]
+	}
 
+	// show where each keyword was used
+	if opts.Snippets != nil {
+		snippets := getKeywordSnippets(original, tokens, keywordTerms(keywords), wordSplitter, *opts.Snippets)
+		for i := range keywords {
+			keywords[i].Snippets = snippets[keywords[i].Term]
+		}
+	}
+	// and trace each keyword back to the sentences it came from
+	if opts.Sentences {
+		paragraphs := segmentText(original)
+		for i := range keywords {
+			keywords[i].Sentences = findKeywordSentences(paragraphs, tokens, keywordWords(keywords[i].Term, wordSplitter))
+		}
+	}
+
+	return keywords, nil
+}
+
+// returns just the terms of a list of keywords
+func keywordTerms(keywords []Keyword) []string {
+	terms := make([]string, 0, len(keywords))
+	for _, kw := range keywords {
+		terms = append(terms, kw.Term)
+	}
+	return terms
 }
 
 // finds keywords for text from a given filepath
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// InvertedIndex maps each keyword to the documents it was extracted from along with its score there
+// Documents lists each document's keywords so a document can be removed or replaced
+type InvertedIndex struct {
+	Postings  map[string]map[string]float64 `json:"postings"`
+	Documents map[string][]string           `json:"documents"`
+}
+
+// the operators understood by boolean queries, they must be written in capitals
+const (
+	queryAnd = "AND"
+	queryOr  = "OR"
+	queryNot = "NOT"
+)
+
+// returns an empty index
+func newInvertedIndex() *InvertedIndex {
+	return &InvertedIndex{
+		Postings:  make(map[string]map[string]float64),
+		Documents: make(map[string][]string),
+	}
+}
+
+// Add indexes a document's keywords, replacing any keywords indexed for it before
+// terms are lowercased as queries are, so vocabulary labels such as "Glasgow Haskell Compiler" can be found,
+// and keywords that only differ in case keep the highest score
+func (index *InvertedIndex) Add(id string, keywords []Keyword) {
+	index.Remove(id)
+
+	terms := make([]string, 0, len(keywords))
+	for _, keyword := range keywords {
+		term := normaliseTerm(keyword.Term)
+		postings, ok := index.Postings[term]
+		if !ok {
+			postings = make(map[string]float64)
+			index.Postings[term] = postings
+		}
+		if score, seen := postings[id]; seen {
+			postings[id] = max(score, keyword.Score)
+			continue
+		}
+		postings[id] = keyword.Score
+		terms = append(terms, term)
+	}
+	index.Documents[id] = terms
+}
+
+// Remove takes a document out of the index, reporting whether it was there
+func (index *InvertedIndex) Remove(id string) bool {
+	terms, ok := index.Documents[id]
+	if !ok {
+		return false
+	}
+	for _, term := range terms {
+		delete(index.Postings[term], id)
+		if len(index.Postings[term]) == 0 {
+			delete(index.Postings, term)
+		}
+	}
+	delete(index.Documents, id)
+	return true
+}
+
+// Lookup returns the documents tagged with a keyword, highest scoring first
+func (index *InvertedIndex) Lookup(term string) []DocumentScore {
+	return rankDocumentScores(index.Postings[normaliseTerm(term)], 0)
+}
+
+// Search runs a boolean query such as `haskell AND (monads OR "type classes") NOT rust`
+// keywords next to each other must both match, and phrases in quotes match multi-word keywords
+// matching documents are ranked by the summed scores of the keywords they matched, NOT keywords aside
+// k limits the results, 0 returns every match
+func (index *InvertedIndex) Search(query string, k int) ([]DocumentScore, error) {
+	tokens, err := queryTokens(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("empty query")
+	}
+
+	parser := queryParser{index: index, tokens: tokens}
+	matches, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if parser.pos < len(tokens) {
+		return nil, errors.New("unexpected " + tokens[parser.pos] + " in query")
+	}
+
+	// score matches by the keywords asked for, so NOT rust does not add rust's score
+	scores := make(map[string]float64, len(matches))
+	for id := range matches {
+		scores[id] = 0
+		for _, term := range parser.scored {
+			scores[id] += index.Postings[term][id]
+		}
+	}
+	return rankDocumentScores(scores, k), nil
+}
+
+// RankedSearch returns the documents tagged with any of the terms, ranked by the summed scores of those they have
+func (index *InvertedIndex) RankedSearch(terms []string, k int) []DocumentScore {
+	scores := make(map[string]float64)
+	for _, term := range terms {
+		for id, score := range index.Postings[normaliseTerm(term)] {
+			scores[id] += score
+		}
+	}
+	return rankDocumentScores(scores, k)
+}
+
+// sorts documents by score, best first with ties by id, keeping the top k or every one when k is 0
+func rankDocumentScores(scores map[string]float64, k int) []DocumentScore {
+	results := make([]DocumentScore, 0, len(scores))
+	for id, score := range scores {
+		results = append(results, DocumentScore{ID: id, Score: score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	if k > 0 && len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+// splits a query into parentheses, operators and keywords, quoted phrases are kept whole and keywords lowercased
+func queryTokens(query string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"':
+			end := strings.IndexByte(query[i+1:], '"')
+			if end == -1 {
+				return nil, errors.New("unterminated quote in query")
+			}
+			phrase := normaliseTerm(query[i+1 : i+1+end])
+			if phrase == "" {
+				return nil, errors.New("empty phrase in query")
+			}
+			// a leading quote keeps phrases apart from operators and parentheses
+			tokens = append(tokens, `"`+phrase)
+			i += end + 2
+		default:
+			end := strings.IndexFunc(query[i:], func(r rune) bool {
+				return unicode.IsSpace(r) || r == '(' || r == ')' || r == '"'
+			})
+			if end == -1 {
+				end = len(query) - i
+			}
+			word := query[i : i+end]
+			if word != queryAnd && word != queryOr && word != queryNot {
+				word = `"` + strings.ToLower(word)
+			}
+			tokens = append(tokens, word)
+			i += end
+		}
+	}
+	return tokens, nil
+}
+
+// a recursive descent parser evaluating a boolean query against an index as it goes
+// NOT binds tightest, then AND, which may be left out between keywords, then OR
+type queryParser struct {
+	index   *InvertedIndex
+	tokens  []string
+	pos     int
+	negated int      // how many NOTs the parser is inside
+	scored  []string // the keywords matches are scored by
+}
+
+// returns the next token without consuming it, or an empty string at the end
+func (p *queryParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+// parses keywords joined by OR, matching documents that match any of them
+func (p *queryParser) parseOr() (map[string]struct{}, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == queryOr {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		for id := range right {
+			left[id] = struct{}{}
+		}
+	}
+	return left, nil
+}
+
+// parses keywords joined by AND or written next to each other, matching documents that match all of them
+func (p *queryParser) parseAnd() (map[string]struct{}, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for next := p.peek(); next != "" && next != ")" && next != queryOr; next = p.peek() {
+		if next == queryAnd {
+			p.pos++
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		for id := range left {
+			if _, ok := right[id]; !ok {
+				delete(left, id)
+			}
+		}
+	}
+	return left, nil
+}
+
+// parses NOT, matching every indexed document that does not match what follows
+func (p *queryParser) parseNot() (map[string]struct{}, error) {
+	if p.peek() != queryNot {
+		return p.parsePrimary()
+	}
+	p.pos++
+
+	p.negated++
+	excluded, err := p.parseNot()
+	p.negated--
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make(map[string]struct{})
+	for id := range p.index.Documents {
+		if _, ok := excluded[id]; !ok {
+			matches[id] = struct{}{}
+		}
+	}
+	return matches, nil
+}
+
+// parses a keyword, phrase or bracketed query
+func (p *queryParser) parsePrimary() (map[string]struct{}, error) {
+	token := p.peek()
+	switch token {
+	case "":
+		return nil, errors.New("query ends where a keyword was expected")
+	case ")", queryAnd, queryOr:
+		return nil, errors.New("expected a keyword before " + token + " in query")
+	}
+	p.pos++
+
+	if token == "(" {
+		matches, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, errors.New("missing ) in query")
+		}
+		p.pos++
+		return matches, nil
+	}
+
+	term := token[1:]
+	if p.negated%2 == 0 {
+		p.scored = append(p.scored, term)
+	}
+	matches := make(map[string]struct{}, len(p.index.Postings[term]))
+	for id := range p.index.Postings[term] {
+		matches[id] = struct{}{}
+	}
+	return matches, nil
+}
+
+// saveInvertedIndex writes the index to path as JSON
+func saveInvertedIndex(path string, index *InvertedIndex) error {
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+// loadInvertedIndex reads an index written by saveInvertedIndex
+func loadInvertedIndex(path string) (*InvertedIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.New("Error opening file, check file path: " + err.Error())
+	}
+	var index InvertedIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, errors.New(path + ": " + err.Error())
+	}
+	if index.Postings == nil || index.Documents == nil {
+		return nil, errors.New(path + ": not an inverted index")
+	}
+	return &index, nil
+}
+
+// runIndex is the index command
+// -add and -remove update the index file, creating it if needed, then -query or -ranked search it
+func runIndex(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("index", flag.ContinueOnError)
+	indexPath := flags.String("index", "", "inverted index file to update or search")
+	add := flags.String("add", "", "extract keywords from this document, JSONL file or directory and add them, replacing documents with the same id")
+	remove := flags.String("remove", "", "comma separated ids of documents to remove")
+	query := flags.String("query", "", `boolean query, e.g. haskell AND (monads OR "type classes") NOT rust`)
+	ranked := flags.String("ranked", "", "comma separated keywords, documents with any of them ranked by summed score")
+	k := flags.Int("k", 10, "number of documents to print, 0 for all")
+	configPath := flags.String("config", "", "JSON, YAML or TOML config file used when adding documents")
+	numKeywords := flags.Int("n", 10, "number of keywords indexed per document")
+	format := flags.String("format", "table", "output format: "+strings.Join(outputFormatNames(), ", "))
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *indexPath == "" {
+		return errors.New("index needs an -index")
+	}
+	if *add == "" && *remove == "" && *query == "" && *ranked == "" {
+		return errors.New("index needs -add, -remove, -query or -ranked")
+	}
+	if *query != "" && *ranked != "" {
+		return errors.New("use either -query or -ranked, not both")
+	}
+
+	config, err := loadConfigWithFlags(*configPath, flags, map[string]func(*Config){
+		"n":      func(c *Config) { c.NumKeywords = *numKeywords },
+		"format": func(c *Config) { c.Format = *format },
+	})
+	if err != nil {
+		return err
+	}
+	if config.Format == "" {
+		config.Format = *format
+	}
+
+	// adding to a missing index starts a new one
+	index := newInvertedIndex()
+	if fileExists(*indexPath) {
+		index, err = loadInvertedIndex(*indexPath)
+		if err != nil {
+			return err
+		}
+	} else if *add == "" {
+		return errors.New("no index at " + *indexPath + ", create one with -add")
+	}
+
+	if *add != "" || *remove != "" {
+		added, removed := 0, 0
+		if *add != "" {
+			opts, err := config.options()
+			if err != nil {
+				return err
+			}
+			docs, err := loadDocumentsOrText(*add)
+			if err != nil {
+				return err
+			}
+			for _, doc := range docs {
+				keywords, err := extractKeywords(doc.Text, opts)
+				if err != nil {
+					continue
+				}
+				index.Add(doc.ID, keywords)
+				added++
+			}
+		}
+		for _, id := range parseNameList(*remove) {
+			if index.Remove(id) {
+				removed++
+			}
+		}
+		if err := saveInvertedIndex(*indexPath, index); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "added %d, removed %d, %d documents indexed\n", added, removed, len(index.Documents))
+	}
+
+	var results []DocumentScore
+	switch {
+	case *query != "":
+		results, err = index.Search(*query, *k)
+		if err != nil {
+			return err
+		}
+	case *ranked != "":
+		results = index.RankedSearch(parseNameList(*ranked), *k)
+	default:
+		return nil
+	}
+	return writeDocumentScores(stdout, config.Format, results)
+}
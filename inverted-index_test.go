@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/*
+This file tests for:
+- adding, replacing and removing documents
+- looking up a keyword
+- boolean queries with AND, OR, NOT, brackets and phrases
+- scoring boolean matches without NOT keywords
+- ranked queries
+- finding keywords mapped onto mixed-case vocabulary labels
+- rejecting malformed queries
+- saving and loading the index
+- the index command adding, removing and searching
+*/
+
+// a small index of three documents
+func testInvertedIndex() *InvertedIndex {
+	index := newInvertedIndex()
+	index.Add("a", []Keyword{{Term: "haskell", Score: 0.5}, {Term: "monads", Score: 0.2}})
+	index.Add("b", []Keyword{{Term: "haskell", Score: 0.3}, {Term: "type classes", Score: 0.4}})
+	index.Add("c", []Keyword{{Term: "rust", Score: 0.6}, {Term: "monads", Score: 0.1}})
+	return index
+}
+
+// returns the ids of search results in order
+func resultIDs(results []DocumentScore) string {
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.ID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestInvertedIndexUpdates(t *testing.T) {
+	index := testInvertedIndex()
+
+	if ids := resultIDs(index.Lookup("Haskell")); ids != "a,b" {
+		t.Errorf("Expected a then b for haskell, got %s", ids)
+	}
+
+	// replacing a document drops its old keywords
+	index.Add("a", []Keyword{{Term: "ocaml", Score: 0.5}})
+	if ids := resultIDs(index.Lookup("haskell")); ids != "b" {
+		t.Errorf("Expected only b for haskell after replacing a, got %s", ids)
+	}
+
+	if !index.Remove("b") || index.Remove("b") {
+		t.Error("Expected b to be removed once")
+	}
+	if _, ok := index.Postings["haskell"]; ok {
+		t.Error("Expected empty postings to be deleted")
+	}
+	if len(index.Documents) != 2 {
+		t.Errorf("Expected 2 documents left, got %d", len(index.Documents))
+	}
+}
+
+func TestInvertedIndexSearch(t *testing.T) {
+	index := testInvertedIndex()
+
+	cases := map[string]string{
+		"haskell":                              "a,b",
+		"haskell AND monads":                   "a",
+		"haskell monads":                       "a",
+		"haskell OR rust":                      "c,a,b",
+		"monads NOT rust":                      "a",
+		"NOT haskell":                          "c",
+		`"Type  Classes"`:                      "b",
+		`haskell AND ("type classes" OR rust)`: "b",
+		"NOT (haskell OR rust)":                "",
+		"python":                               "",
+	}
+	for query, expected := range cases {
+		results, err := index.Search(query, 0)
+		if err != nil {
+			t.Errorf("%s: expected no error, got: %v", query, err)
+			continue
+		}
+		if ids := resultIDs(results); ids != expected {
+			t.Errorf("%s: expected %q, got %q", query, expected, ids)
+		}
+	}
+
+	// NOT keywords add nothing to the score
+	results, _ := index.Search("monads NOT rust", 0)
+	if results[0].Score != 0.2 {
+		t.Errorf("Expected a score of 0.2, got %.2f", results[0].Score)
+	}
+
+	for _, query := range []string{"", "AND haskell", "haskell OR", "(haskell", "haskell)", `"haskell`, `""`} {
+		if _, err := index.Search(query, 0); err == nil {
+			t.Errorf("Expected error for query %q, got nil", query)
+		}
+	}
+}
+
+func TestInvertedIndexRankedSearch(t *testing.T) {
+	index := testInvertedIndex()
+	results := index.RankedSearch([]string{"haskell", "monads"}, 2)
+	if ids := resultIDs(results); ids != "a,b" {
+		t.Errorf("Expected a then b, got %s", ids)
+	}
+	if results[0].Score != 0.7 {
+		t.Errorf("Expected a to score 0.7, got %.2f", results[0].Score)
+	}
+}
+
+func TestInvertedIndexVocabulary(t *testing.T) {
+	vocabulary, err := newVocabulary([]Concept{{ID: "http://example.org/ghc", PrefLabel: "Glasgow Haskell Compiler", AltLabels: []string{"ghc"}}})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	keywords, err := extractKeywords("GHC compiles Haskell. GHC is fast. Rust is not.", ExtractOptions{
+		NumKeywords: 10,
+		Stopwords:   map[string]struct{}{},
+		Vocabulary:  &VocabularyOptions{Vocabulary: vocabulary},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if keywords[0].Term != "Glasgow Haskell Compiler" {
+		t.Fatalf("Expected 'Glasgow Haskell Compiler' first, got %q", keywords[0].Term)
+	}
+
+	index := newInvertedIndex()
+	index.Add("a", keywords)
+	if ids := resultIDs(index.Lookup("Glasgow Haskell Compiler")); ids != "a" {
+		t.Errorf("Expected a for the label, got %q", ids)
+	}
+	results, err := index.Search(`"glasgow haskell compiler" AND rust`, 0)
+	if err != nil || resultIDs(results) != "a" {
+		t.Errorf("Expected a for the phrase query, got %q and %v", resultIDs(results), err)
+	}
+	if ids := resultIDs(index.RankedSearch([]string{"glasgow  haskell compiler"}, 0)); ids != "a" {
+		t.Errorf("Expected a for the ranked query, got %q", ids)
+	}
+}
+
+func TestInvertedIndexPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json")
+	if err := saveInvertedIndex(path, testInvertedIndex()); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	loaded, err := loadInvertedIndex(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if ids := resultIDs(loaded.Lookup("monads")); ids != "a,c" {
+		t.Errorf("Expected a and c after loading, got %s", ids)
+	}
+
+	os.WriteFile(path, []byte(`{}`), 0644)
+	if _, err := loadInvertedIndex(path); err == nil {
+		t.Error("Expected error for a file that is not an index, got nil")
+	}
+}
+
+func TestRunIndex(t *testing.T) {
+	dir := t.TempDir()
+	dataset := filepath.Join(dir, "docs.jsonl")
+	os.WriteFile(dataset, []byte(`{"id":"h","text":"Haskell monads. Haskell laziness."}`+"\n"+`{"id":"r","text":"Rust ownership. Rust borrowing."}`+"\n"), 0644)
+	indexPath := filepath.Join(dir, "index.json")
+
+	var out bytes.Buffer
+	if err := runIndex([]string{"-index", indexPath, "-query", "haskell"}, &out); err == nil {
+		t.Error("Expected error searching a missing index, got nil")
+	}
+
+	if err := runIndex([]string{"-index", indexPath, "-add", dataset}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if out.String() != "added 2, removed 0, 2 documents indexed\n" {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+
+	out.Reset()
+	if err := runIndex([]string{"-index", indexPath, "-query", "haskell OR rust", "-format", "plain"}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if out.String() != "h\nr\n" && out.String() != "r\nh\n" {
+		t.Errorf("Expected both documents, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := runIndex([]string{"-index", indexPath, "-remove", "h", "-ranked", "haskell,rust", "-format", "plain"}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if out.String() != "added 0, removed 1, 1 documents indexed\nr\n" {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+}
@@ -1,18 +1,102 @@
 package main
 
-import "fmt"
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// commands maps each subcommand name to the function that runs it with the remaining arguments
+var commands = map[string]func(args []string, stdout io.Writer) error{
+	"compare":      runCompare,
+	"corpus":       runCorpus,
+	"eval":         runEval,
+	"extract":      runExtract,
+	"index":        runIndex,
+	"similar":      runSimilar,
+	"sweep":        runSweepCommand,
+	"train-tagger": runTrainTagger,
+	"trend":        runTrend,
+	"watch":        runWatch,
+}
 
 func main() {
+	// with no command, print keywords for the built in sample
+	if len(os.Args) < 2 {
+		runDemo()
+		return
+	}
 
-	content := "Haskell (/hæskəl/[25]) is a general-purpose, statically typed, purely functional programming language with type inference and lazy evaluation.[26][27] Haskell pioneered several programming language features such as type classes, which enable type-safe operator overloading, and monadic input/output (IO). It is named after logician Haskell Curry.[1] Haskell's main implementation is the Glasgow Haskell Compiler (GHC). \n\n Haskell's semantics are historically based on those of the Miranda programming language, which served to focus the efforts of the initial Haskell working group.[28] The last formal specification of the language was made in July 2010, while the development of GHC continues to expand Haskell via language extensions. \n\n Haskell is used in academia and industry.[29][30][31] As of May 2021, Haskell was the 28th most popular programming language by Google searches for tutorials,[32] and made up less than 1 percent of active users on the GitHub source code repository.[33] Haskell features lazy evaluation, lambda expressions, pattern matching, list comprehension, type classes and type polymorphism. It is a purely functional programming language, which means that functions generally have no side effects. A distinct construct exists to represent side effects, orthogonal to the type of functions. A pure function can return a side effect that is subsequently executed, modeling the impure functions of other languages.\n\n Haskell has a strong, static type system based on Hindley–Milner type inference. Its principal innovation in this area is type classes, originally conceived as a principled way to add overloading to the language,[41] but since finding many more uses.[42] \n\n The construct that represents side effects is an example of a monad: a general framework which can model various computations such as error handling, nondeterminism, parsing and software transactional memory. They are defined as ordinary datatypes, but Haskell provides some syntactic sugar for their use. \n\n Haskell has an open, published specification,[27] and multiple implementations exist. Its main implementation, the Glasgow Haskell Compiler (GHC), is both an interpreter and native-code compiler that runs on most platforms. GHC is noted for its rich type system incorporating recent innovations such as generalized algebraic data types and type families. The Computer Language Benchmarks Game also highlights its high-performance implementation of concurrency and parallelism.[43] \n\n An active, growing community exists around the language, and more than 5,400 third-party open-source libraries and tools are available in the online package repository Hackage.[44]"
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintln(os.Stderr, "Unknown command:", os.Args[1])
+		printUsage(os.Stderr)
+		os.Exit(2)
+	}
+	if err := command(os.Args[2:], os.Stdout); err != nil {
+		// the flag package has already printed the usage
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+// lists the available commands
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-	stringKeywords := getStringKeywords(content, 5)
+	fmt.Fprintln(w, "Usage: keyword-extractor [command] [flags]")
+	fmt.Fprintln(w, "Commands:")
+	for _, name := range names {
+		fmt.Fprintln(w, "  "+name)
+	}
+	fmt.Fprintln(w, "Run a command with -h to see its flags, or no command to run the sample")
+}
+
+// prints keywords for the haskell sample text and the sample file
+func runDemo() {
+
+	content := "Haskell (/hæskəl/[25]) is a general-purpose, statically typed, purely functional programming language with type inference and lazy evaluation.[26][27] Haskell pioneered several programming language features such as type classes, which enable type-safe operator overloading, and monadic input/output (IO). It is named after logician Haskell Curry.[1] Haskell's main implementation is the Glasgow Haskell Compiler (GHC). \n\n Haskell's semantics are historically based on those of the Miranda programming language, which served to focus the efforts of the initial Haskell working group.[28] The last formal specification of the language was made in July 2010, while the development of GHC continues to expand Haskell via language extensions. \n\n Haskell is used in academia and industry.[29][30][31] As of May 2021, Haskell was the 28th most popular programming language by Google searches for tutorials,[32] and made up less than 1 percent of active users on the GitHub source code repository.[33] Haskell features lazy evaluation, lambda expressions, pattern matching, list comprehension, type classes and type polymorphism. It is a purely functional programming language, which means that functions generally have no side effects. A distinct construct exists to represent side effects, orthogonal to the type of functions. A pure function can return a side effect that is subsequently executed, modeling the impure functions of other languages.\n\n Haskell has a strong, static type system based on Hindley–Milner type inference. Its principal innovation in this area is type classes, originally conceived as a principled way to add overloading to the language,[41] but since finding many more uses.[42] \n\n The construct that represents side effects is an example of a monad: a general framework which can model various computations such as error handling, nondeterminism, parsing and software transactional memory. They are defined as ordinary datatypes, but Haskell provides some syntactic sugar for their use. \n\n Haskell has an open, published specification,[27] and multiple implementations exist. Its main implementation, the Glasgow Haskell Compiler (GHC), is both an interpreter and native-code compiler that runs on most platforms. GHC is noted for its rich type system incorporating recent innovations such as generalized algebraic data types and type families. The Computer Language Benchmarks Game also highlights its high-performance implementation of concurrency and parallelism.[43] \n\n An active, growing community exists around the language, and more than 5,400 third-party open-source libraries and tools are available in the online package repository Hackage.[44]"
 
-	fmt.Println("String keywords: ", stringKeywords)
+	stopwords, err := LoadStopwords(defaultStopwordsPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return
+	}
+	opts := ExtractOptions{NumKeywords: 5, Stopwords: stopwords}
+	writer := TableWriter{}
 
-	fileKeywords := getFileKeywords("./data/sample.txt", 5) // use term frequency from file
+	stringKeywords, err := extractKeywords(content, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error extracting keywords:", err)
+		return
+	}
+	fmt.Println("String keywords:")
+	writer.WriteKeywords(os.Stdout, stringKeywords)
 
-	fmt.Println("File keywords: ", fileKeywords)
+	// use term frequency from file
+	fileContent, err := LoadFileContent("./data/sample.txt")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error loading file content:", err)
+		return
+	}
+	fileKeywords, err := extractKeywords(fileContent, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error extracting keywords:", err)
+		return
+	}
+	fmt.Println()
+	fmt.Println("File keywords:")
+	writer.WriteKeywords(os.Stdout, fileKeywords)
 
 	// get keywords for files based on tf-idf
 	//filepaths := []string{"./data/sample.txt", "./data/sample2.txt"}
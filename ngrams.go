@@ -0,0 +1,194 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+)
+
+// NgramCountIndex maps space separated n-grams such as "type classes" to how often they occur
+type NgramCountIndex map[string]int
+
+// Collocation is an n-gram whose words occur together more often than chance would suggest
+type Collocation struct {
+	Term   string
+	Words  []string
+	Count  int
+	PMI    float64
+	LLR    float64
+	TScore float64
+}
+
+// CollocationOptions controls which n-grams are promoted into the candidate keywords
+// Measure is one of "pmi", "llr" or "tscore" and n-grams scoring at least Threshold on it are promoted
+type CollocationOptions struct {
+	MaxN      int
+	MinCount  int
+	Measure   string
+	Threshold float64
+}
+
+// Counts every run of n adjacent tokens that were counted in tci
+// tokens only count as adjacent when separated by whitespace or a hyphen so n-grams never cross punctuation
+func getNgramCount(content string, tokens []Token, tci TermCountIndex, n int) NgramCountIndex {
+	nci := make(NgramCountIndex)
+
+	for i := 0; i+n <= len(tokens); i++ {
+		words := make([]string, 0, n)
+		for j := i; j < i+n; j++ {
+			if _, ok := tci[tokens[j].Text]; !ok {
+				break
+			}
+			if j > i && strings.Trim(content[tokens[j-1].End:tokens[j].Start], " \t-") != "" {
+				break
+			}
+			words = append(words, tokens[j].Text)
+		}
+		if len(words) == n {
+			nci[strings.Join(words, " ")]++
+		}
+	}
+
+	return nci
+}
+
+// Finds bigrams and, when opts.MaxN is 3, trigrams and scores them with PMI, log-likelihood ratio and t-score
+// tci must hold the counts of the single words counted from tokens
+// only n-grams seen at least opts.MinCount times and at or above opts.Threshold on opts.Measure are returned
+func detectCollocations(content string, tokens []Token, tci TermCountIndex, opts CollocationOptions) ([]Collocation, error) {
+	if opts.MaxN < 2 || opts.MaxN > 3 {
+		return nil, errors.New("collocation MaxN must be 2 or 3")
+	}
+	if _, ok := associationMeasures[opts.Measure]; !ok {
+		return nil, errors.New("unknown association measure: " + opts.Measure)
+	}
+
+	// counts of every n-gram size, index 1 holds the single words
+	counts := []NgramCountIndex{nil, NgramCountIndex(tci)}
+	for n := 2; n <= opts.MaxN; n++ {
+		counts = append(counts, getNgramCount(content, tokens, tci, n))
+	}
+
+	total := float64(len(tokens))
+	var collocations []Collocation
+
+	for n := 2; n <= opts.MaxN; n++ {
+		for term, count := range counts[n] {
+			if count < opts.MinCount {
+				continue
+			}
+			words := strings.Split(term, " ")
+
+			// expected count if the words were independent
+			chance := 1.0
+			for _, word := range words {
+				chance *= float64(tci[word]) / total
+			}
+			expected := chance * total
+
+			// the log-likelihood ratio compares the leading n-1 words against the last word
+			prefix := counts[n-1][strings.Join(words[:n-1], " ")]
+			last := tci[words[n-1]]
+
+			c := Collocation{
+				Term:   term,
+				Words:  words,
+				Count:  count,
+				PMI:    math.Log2(float64(count) / expected),
+				LLR:    logLikelihoodRatio(count, prefix, last, len(tokens)),
+				TScore: (float64(count) - expected) / math.Sqrt(float64(count)),
+			}
+			if associationMeasures[opts.Measure](c) >= opts.Threshold {
+				collocations = append(collocations, c)
+			}
+		}
+	}
+
+	// strongest first, ties broken alphabetically
+	sort.Slice(collocations, func(i, j int) bool {
+		si, sj := associationMeasures[opts.Measure](collocations[i]), associationMeasures[opts.Measure](collocations[j])
+		if si != sj {
+			return si > sj
+		}
+		return collocations[i].Term < collocations[j].Term
+	})
+
+	return collocations, nil
+}
+
+// associationMeasures maps the names used in options to the score they read from a collocation
+var associationMeasures = map[string]func(Collocation) float64{
+	"pmi":    func(c Collocation) float64 { return c.PMI },
+	"llr":    func(c Collocation) float64 { return c.LLR },
+	"tscore": func(c Collocation) float64 { return c.TScore },
+}
+
+// Dunning's log-likelihood ratio (G squared) for two events seen together k11 times
+// countA and countB are how often each event was seen at all and total is the number of observations
+func logLikelihoodRatio(k11, countA, countB, total int) float64 {
+	k12 := countA - k11
+	k21 := countB - k11
+	k22 := total - k11 - k12 - k21
+
+	// counts can disagree slightly when n-grams overlap, so clamp the contingency table at zero
+	cells := [2][2]float64{
+		{math.Max(float64(k11), 0), math.Max(float64(k12), 0)},
+		{math.Max(float64(k21), 0), math.Max(float64(k22), 0)},
+	}
+	rows := [2]float64{cells[0][0] + cells[0][1], cells[1][0] + cells[1][1]}
+	cols := [2]float64{cells[0][0] + cells[1][0], cells[0][1] + cells[1][1]}
+	n := rows[0] + rows[1]
+
+	g2 := 0.0
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2; j++ {
+			if cells[i][j] > 0 {
+				g2 += cells[i][j] * math.Log(cells[i][j]*n/(rows[i]*cols[j]))
+			}
+		}
+	}
+
+	return 2 * g2
+}
+
+// Adds collocations to the candidate keywords so they can be ranked as single terms
+// each collocation scores as if every word in it was counted once per occurrence, and those
+// occurrences are taken away from its words so "type classes" is not also counted as "type" and "classes"
+// collocations overlapping a stronger one, such as "type classes" inside "haskell type classes", are skipped
+// tfi and tci are updated in place, totalWords is the denominator used by getWordFrequency
+func promoteCollocations(tfi TermFrequencyIndex, tci TermCountIndex, collocations []Collocation, totalWords int) {
+	var promoted []string
+
+	for _, c := range collocations {
+		if overlapsAny(c.Term, promoted) {
+			continue
+		}
+		promoted = append(promoted, c.Term)
+
+		tci[c.Term] = c.Count
+		tfi[c.Term] = float64(c.Count*len(c.Words)) / float64(totalWords)
+
+		for _, word := range c.Words {
+			tci[word] -= c.Count
+			if tci[word] <= 0 {
+				delete(tci, word)
+				delete(tfi, word)
+				continue
+			}
+			tfi[word] = float64(tci[word]) / float64(totalWords)
+		}
+	}
+}
+
+// checks if term contains, or is contained in, any of the given phrases on word boundaries
+func overlapsAny(term string, phrases []string) bool {
+	padded := " " + term + " "
+	for _, phrase := range phrases {
+		other := " " + phrase + " "
+		if strings.Contains(padded, other) || strings.Contains(other, padded) {
+			return true
+		}
+	}
+	return false
+}
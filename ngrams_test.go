@@ -0,0 +1,160 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+/*
+This file tests for:
+- bigram and trigram counting
+- n-grams do not cross punctuation or stopwords
+- log-likelihood ratio against a worked example
+- collocation scores and thresholds
+- rejecting bad options
+- promoting collocations into the candidate set
+- collocations in the extraction pipeline
+*/
+func TestGetNgramCount(t *testing.T) {
+	stopwords := map[string]struct{}{"the": {}, "and": {}}
+
+	// Test counting bigrams and trigrams
+	t.Run("Counts", func(t *testing.T) {
+		content := "type classes and type classes with type-classes systems"
+		tokens := tokenize(content, defaultWordSplitter)
+
+		tci, _ := getTokenCount(tokens, defaultTokenFilterChain(stopwords, defaultMinWordLength))
+		bigrams := getNgramCount(content, tokens, tci, 2)
+		if bigrams["type classes"] != 3 {
+			t.Errorf("Expected 3 'type classes', got %d", bigrams["type classes"])
+		}
+		if _, ok := bigrams["classes and"]; ok {
+			t.Error("Bigram with stopword 'and' should not be counted")
+		}
+
+		trigrams := getNgramCount(content, tokens, tci, 3)
+		if trigrams["type classes with"] != 1 || trigrams["type classes systems"] != 1 {
+			t.Errorf("Expected trigrams to be counted once each, got %v", trigrams)
+		}
+	})
+
+	// Test n-grams stop at punctuation
+	t.Run("Punctuation", func(t *testing.T) {
+		content := "lazy evaluation. evaluation order, lazy evaluation"
+		tokens := tokenize(content, defaultWordSplitter)
+
+		tci, _ := getTokenCount(tokens, defaultTokenFilterChain(stopwords, defaultMinWordLength))
+		bigrams := getNgramCount(content, tokens, tci, 2)
+		if bigrams["lazy evaluation"] != 2 {
+			t.Errorf("Expected 2 'lazy evaluation', got %d", bigrams["lazy evaluation"])
+		}
+		if _, ok := bigrams["evaluation evaluation"]; ok {
+			t.Error("Bigram across a full stop should not be counted")
+		}
+		if _, ok := bigrams["order lazy"]; ok {
+			t.Error("Bigram across a comma should not be counted")
+		}
+	})
+}
+
+func TestLogLikelihoodRatio(t *testing.T) {
+	// independent events score zero
+	if g2 := logLikelihoodRatio(25, 50, 50, 100); math.Abs(g2) > 0.0001 {
+		t.Errorf("Expected 0 for independent events, got %.4f", g2)
+	}
+
+	// 2x2 table {{10, 0}, {0, 90}}: G2 = 2 * (10 ln 10 + 90 ln(100/90))
+	expected := 2 * (10*math.Log(10) + 90*math.Log(100.0/90.0))
+	if g2 := logLikelihoodRatio(10, 10, 10, 100); math.Abs(g2-expected) > 0.0001 {
+		t.Errorf("Expected %.4f, got %.4f", expected, g2)
+	}
+}
+
+func TestDetectCollocations(t *testing.T) {
+	stopwords := map[string]struct{}{"the": {}, "and": {}, "has": {}}
+	content := "Haskell has side effects. Side effects are modelled. The monad wraps side effects. " +
+		"Haskell is lazy. Effects vary. Side dishes differ. Haskell monad code."
+	tokens := tokenize(content, defaultWordSplitter)
+	tci, err := getTokenCount(tokens, defaultTokenFilterChain(stopwords, defaultMinWordLength))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	// Test scores for a strong collocation
+	t.Run("Scores", func(t *testing.T) {
+		collocations, err := detectCollocations(content, tokens, tci, CollocationOptions{MaxN: 2, MinCount: 2, Measure: "pmi"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(collocations) != 1 || collocations[0].Term != "side effects" {
+			t.Fatalf("Expected only 'side effects', got %v", collocations)
+		}
+
+		c := collocations[0]
+		total := float64(len(tokens))
+		expectedPMI := math.Log2(3 * total / (4 * 4))
+		if math.Abs(c.PMI-expectedPMI) > 0.0001 {
+			t.Errorf("Expected PMI %.4f, got %.4f", expectedPMI, c.PMI)
+		}
+		if c.LLR <= 0 || c.TScore <= 0 {
+			t.Errorf("Expected positive LLR and t-score, got %.4f and %.4f", c.LLR, c.TScore)
+		}
+	})
+
+	// Test the threshold filters weak collocations
+	t.Run("Threshold", func(t *testing.T) {
+		collocations, err := detectCollocations(content, tokens, tci, CollocationOptions{MaxN: 2, MinCount: 1, Measure: "llr", Threshold: 1000})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(collocations) != 0 {
+			t.Errorf("Expected no collocations above threshold, got %v", collocations)
+		}
+	})
+
+	// Test bad options are rejected
+	t.Run("InvalidOptions", func(t *testing.T) {
+		if _, err := detectCollocations(content, tokens, tci, CollocationOptions{MaxN: 4, Measure: "pmi"}); err == nil {
+			t.Error("Expected error for MaxN 4, got nil")
+		}
+		if _, err := detectCollocations(content, tokens, tci, CollocationOptions{MaxN: 2, Measure: "dice"}); err == nil {
+			t.Error("Expected error for unknown measure, got nil")
+		}
+	})
+}
+
+func TestPromoteCollocations(t *testing.T) {
+	tci := TermCountIndex{"side": 4, "effects": 3, "haskell": 2}
+	tfi := TermFrequencyIndex{"side": 0.4, "effects": 0.3, "haskell": 0.2}
+	collocations := []Collocation{
+		{Term: "side effects", Words: []string{"side", "effects"}, Count: 3},
+		{Term: "effects", Words: []string{"effects"}, Count: 1},
+	}
+
+	promoteCollocations(tfi, tci, collocations, 10)
+
+	if tci["side effects"] != 3 || math.Abs(tfi["side effects"]-0.6) > 0.0001 {
+		t.Errorf("Expected 'side effects' count 3 and score 0.6, got %d and %.4f", tci["side effects"], tfi["side effects"])
+	}
+	if tci["side"] != 1 || math.Abs(tfi["side"]-0.1) > 0.0001 {
+		t.Errorf("Expected 'side' count 1 and score 0.1, got %d and %.4f", tci["side"], tfi["side"])
+	}
+	if _, ok := tfi["effects"]; ok {
+		t.Error("Expected 'effects' to be removed once all its occurrences were promoted")
+	}
+}
+
+func TestExtractKeywordsWithCollocations(t *testing.T) {
+	content := "Lazy evaluation is common. Lazy evaluation helps. Lazy evaluation wins. Haskell code."
+	keywords, err := extractKeywords(content, ExtractOptions{
+		NumKeywords:  1,
+		Stopwords:    map[string]struct{}{},
+		Collocations: &CollocationOptions{MaxN: 2, MinCount: 2, Measure: "tscore"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if keywords[0].Term != "lazy evaluation" {
+		t.Errorf("Expected 'lazy evaluation', got %q", keywords[0].Term)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// ResultWriter writes a ranked list of keywords, or the records of any other result, to w in one output format
+type ResultWriter interface {
+	WriteKeywords(w io.Writer, keywords []Keyword) error
+	WriteRecords(w io.Writer, records Records) error
+}
+
+// Records is a result laid out for every output format
+// the CSV, TSV and table writers write Columns and Rows, the plain writer writes Lines and the JSON writer
+// encodes Value, Header and Footer are lines the table writer adds above and below the rows
+type Records struct {
+	Columns []string
+	Rows    [][]string
+	Lines   []string
+	Value   any
+	Header  []string
+	Footer  []string
+}
+
+// PlainWriter writes one term per line with nothing else, for piping into other tools
+type PlainWriter struct{}
+
+// JSONWriter writes the keywords as a JSON array of objects with their scores, counts and any snippets
+type JSONWriter struct {
+	Indent bool
+}
+
+// DelimitedWriter writes a header and one row per keyword separated by Comma, so CSV or TSV
+type DelimitedWriter struct {
+	Comma rune
+}
+
+// TableWriter writes the keywords as a table aligned for reading in a terminal
+type TableWriter struct{}
+
+// DocumentScore is a document found by a search along with its score
+type DocumentScore struct {
+	ID    string  `json:"id"`
+	Score float64 `json:"score"`
+}
+
+// resultWriters maps the names accepted by -format and config files to their writers
+var resultWriters = map[string]ResultWriter{
+	"plain": PlainWriter{},
+	"json":  JSONWriter{Indent: true},
+	"csv":   DelimitedWriter{Comma: ','},
+	"tsv":   DelimitedWriter{Comma: '\t'},
+	"table": TableWriter{},
+}
+
+// returns the writer for a named output format
+func newResultWriter(format string) (ResultWriter, error) {
+	writer, ok := resultWriters[format]
+	if !ok {
+		return nil, errors.New("unknown output format " + format + ", expected one of: " + strings.Join(outputFormatNames(), ", "))
+	}
+	return writer, nil
+}
+
+// returns the names of every output format in alphabetical order
+func outputFormatNames() []string {
+	names := make([]string, 0, len(resultWriters))
+	for name := range resultWriters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// the columns written by the CSV, TSV and table writers
+var keywordColumns = []string{"rank", "term", "score", "count"}
+
+// the values for one keyword in the same order as keywordColumns
+func keywordRow(rank int, keyword Keyword) []string {
+	return []string{
+		strconv.Itoa(rank),
+		keyword.Term,
+		strconv.FormatFloat(keyword.Score, 'f', 6, 64),
+		strconv.Itoa(keyword.Count),
+	}
+}
+
+// lays out keywords as records, an empty list is written as [] rather than null
+func keywordRecords(keywords []Keyword) Records {
+	if keywords == nil {
+		keywords = []Keyword{}
+	}
+	records := Records{Columns: keywordColumns, Value: keywords}
+	for i, keyword := range keywords {
+		records.Rows = append(records.Rows, keywordRow(i+1, keyword))
+		records.Lines = append(records.Lines, keyword.Term)
+	}
+	return records
+}
+
+// WriteKeywords writes each term on its own line
+func (pw PlainWriter) WriteKeywords(w io.Writer, keywords []Keyword) error {
+	return pw.WriteRecords(w, keywordRecords(keywords))
+}
+
+// WriteRecords writes each line of the records
+func (PlainWriter) WriteRecords(w io.Writer, records Records) error {
+	for _, line := range records.Lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteKeywords writes the keywords as a JSON array
+func (jw JSONWriter) WriteKeywords(w io.Writer, keywords []Keyword) error {
+	return jw.WriteRecords(w, keywordRecords(keywords))
+}
+
+// WriteRecords encodes the value of the records
+func (jw JSONWriter) WriteRecords(w io.Writer, records Records) error {
+	encoder := json.NewEncoder(w)
+	if jw.Indent {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder.Encode(records.Value)
+}
+
+// WriteKeywords writes a header row then one row per keyword
+func (dw DelimitedWriter) WriteKeywords(w io.Writer, keywords []Keyword) error {
+	return dw.WriteRecords(w, keywordRecords(keywords))
+}
+
+// WriteRecords writes the columns as a header row then every row
+func (dw DelimitedWriter) WriteRecords(w io.Writer, records Records) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = dw.Comma
+	if err := cw.Write(records.Columns); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(records.Rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// WriteKeywords writes the keywords as aligned columns
+func (tw TableWriter) WriteKeywords(w io.Writer, keywords []Keyword) error {
+	return tw.WriteRecords(w, keywordRecords(keywords))
+}
+
+// WriteRecords writes the header lines, the columns and rows aligned, then the footer lines
+func (TableWriter) WriteRecords(w io.Writer, records Records) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, line := range records.Header {
+		fmt.Fprintln(tw, line)
+	}
+	fmt.Fprintln(tw, strings.Join(records.Columns, "\t"))
+	for _, row := range records.Rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	for _, line := range records.Footer {
+		fmt.Fprintln(tw, line)
+	}
+	return tw.Flush()
+}
+
+// the columns written for search results by every format but json and plain
+var documentScoreColumns = []string{"rank", "id", "score"}
+
+// writes search results in one of the formats accepted by newResultWriter
+// plain writes one document id per line
+func writeDocumentScores(w io.Writer, format string, results []DocumentScore) error {
+	writer, err := newResultWriter(format)
+	if err != nil {
+		return err
+	}
+
+	if results == nil {
+		results = []DocumentScore{}
+	}
+	records := Records{Columns: documentScoreColumns, Value: results}
+	for i, result := range results {
+		records.Rows = append(records.Rows, []string{strconv.Itoa(i + 1), result.ID, strconv.FormatFloat(result.Score, 'f', 4, 64)})
+		records.Lines = append(records.Lines, result.ID)
+	}
+	return writer.WriteRecords(w, records)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+/*
+This file tests for:
+- each writer includes the terms with their scores and counts
+- CSV quoting and TSV separators
+- JSON round trips and writes [] for no keywords
+- every writer lays out other results from their records
+- looking writers up by name
+- the extract command's -format flag
+*/
+func TestResultWriters(t *testing.T) {
+	keywords := []Keyword{
+		{Term: "haskell", Score: 0.5, Count: 10},
+		{Term: "type, classes", Score: 0.25, Count: 5},
+	}
+
+	// Test plain output is one term per line
+	t.Run("Plain", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := (PlainWriter{}).WriteKeywords(&out, keywords); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if out.String() != "haskell\ntype, classes\n" {
+			t.Errorf("Unexpected plain output: %q", out.String())
+		}
+	})
+
+	// Test JSON round trips
+	t.Run("JSON", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := (JSONWriter{}).WriteKeywords(&out, keywords); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		var decoded []Keyword
+		if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+			t.Fatalf("Expected valid JSON, got: %v", err)
+		}
+		if len(decoded) != 2 || !reflect.DeepEqual(decoded[1], keywords[1]) {
+			t.Errorf("Expected keywords to round trip, got %+v", decoded)
+		}
+		if strings.Contains(out.String(), "snippets") {
+			t.Error("Expected snippets to be left out when there are none")
+		}
+
+		out.Reset()
+		(JSONWriter{}).WriteKeywords(&out, nil)
+		if strings.TrimSpace(out.String()) != "[]" {
+			t.Errorf("Expected [] for no keywords, got %q", out.String())
+		}
+	})
+
+	// Test CSV and TSV parse back into the same rows
+	t.Run("Delimited", func(t *testing.T) {
+		for _, comma := range []rune{',', '\t'} {
+			var out bytes.Buffer
+			if err := (DelimitedWriter{Comma: comma}).WriteKeywords(&out, keywords); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			reader := csv.NewReader(&out)
+			reader.Comma = comma
+			rows, err := reader.ReadAll()
+			if err != nil {
+				t.Fatalf("Expected valid output, got: %v", err)
+			}
+			if len(rows) != 3 || strings.Join(rows[0], " ") != "rank term score count" {
+				t.Fatalf("Expected a header and two rows, got %v", rows)
+			}
+			if strings.Join(rows[2], "|") != "2|type, classes|0.250000|5" {
+				t.Errorf("Unexpected row: %v", rows[2])
+			}
+		}
+	})
+
+	// Test the table lines up its columns
+	t.Run("Table", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := (TableWriter{}).WriteKeywords(&out, keywords); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("Expected 3 lines, got %d", len(lines))
+		}
+		if strings.Index(lines[0], "score") != strings.Index(lines[1], "0.500000") {
+			t.Errorf("Expected the score column to line up:\n%s", out.String())
+		}
+	})
+}
+
+func TestWriteRecords(t *testing.T) {
+	records := Records{
+		Columns: []string{"side", "term"},
+		Rows:    [][]string{{"a", "lazy"}, {"b", "strict"}},
+		Lines:   []string{"+lazy", "-strict"},
+		Value:   map[string][]string{"a": {"lazy"}, "b": {"strict"}},
+		Header:  []string{"compared:"},
+		Footer:  []string{"2 terms"},
+	}
+
+	expected := map[string]string{
+		"plain": "+lazy\n-strict\n",
+		"json":  "{\n  \"a\": [\n    \"lazy\"\n  ],\n  \"b\": [\n    \"strict\"\n  ]\n}\n",
+		"csv":   "side,term\na,lazy\nb,strict\n",
+		"tsv":   "side\tterm\na\tlazy\nb\tstrict\n",
+		"table": "compared:\nside  term\na     lazy\nb     strict\n2 terms\n",
+	}
+	for format, want := range expected {
+		writer, _ := newResultWriter(format)
+		var out bytes.Buffer
+		if err := writer.WriteRecords(&out, records); err != nil {
+			t.Fatalf("%s: expected no error, got: %v", format, err)
+		}
+		if out.String() != want {
+			t.Errorf("%s: expected %q, got %q", format, want, out.String())
+		}
+	}
+}
+
+func TestNewResultWriter(t *testing.T) {
+	for _, name := range []string{"plain", "json", "csv", "tsv", "table"} {
+		if _, err := newResultWriter(name); err != nil {
+			t.Errorf("Expected a writer for %s, got: %v", name, err)
+		}
+	}
+	if _, err := newResultWriter("xml"); err == nil {
+		t.Error("Expected error for unknown format, got nil")
+	}
+}
+
+func TestRunExtractFormat(t *testing.T) {
+	input := writeConfigFile(t, "input.txt", "Haskell is lazy. Haskell has monads. Monads wrap effects in Haskell.")
+
+	var out bytes.Buffer
+	if err := runExtract([]string{"-n", "2", "-format", "csv", input}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected valid CSV, got: %v", err)
+	}
+	if len(rows) != 3 || rows[1][1] != "haskell" || rows[1][3] != "3" {
+		t.Errorf("Expected haskell counted 3 times first, got %v", rows)
+	}
+
+	if err := runExtract([]string{"-format", "xml", input}, &out); err == nil {
+		t.Error("Expected error for unknown format, got nil")
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// POSPattern matches runs of part-of-speech tags, such as (ADJ)*(NOUN)+ for noun phrases
+// each tag is mapped to a single letter so the pattern can be run as a regular expression over a sentence
+type POSPattern struct {
+	Source string
+	re     *regexp.Regexp
+}
+
+// the tags patterns may use, each is written into the tag string as its own letter
+var posTagLetters = map[string]byte{
+	"NOUN": 'n', "VERB": 'v', "ADJ": 'j', "ADV": 'r', "PRON": 'p', "DET": 'd',
+	"ADP": 'a', "NUM": 'c', "CONJ": 'o', "PRT": 't', "PUNCT": '.', "X": 'x',
+}
+
+// matches one element of a pattern, either a bare tag or a group of alternatives, and its quantifier
+var posPatternElement = regexp.MustCompile(`^\s*(?:\(([A-Z|\s]+)\)|([A-Z]+))([*+?]?)`)
+
+// compilePOSPattern parses a pattern such as (ADJ)*(NOUN)+ or (ADJ|NOUN)*NOUN
+func compilePOSPattern(source string) (*POSPattern, error) {
+	var expr strings.Builder
+
+	rest := strings.TrimSpace(source)
+	if rest == "" {
+		return nil, errors.New("empty part-of-speech pattern")
+	}
+	for rest != "" {
+		match := posPatternElement.FindStringSubmatch(rest)
+		if match == nil {
+			return nil, errors.New("invalid part-of-speech pattern near: " + rest)
+		}
+
+		// a group lists its tags separated by |, a bare tag is a group of one
+		tags := strings.Split(match[1]+match[2], "|")
+		expr.WriteByte('[')
+		for _, tag := range tags {
+			letter, ok := posTagLetters[strings.TrimSpace(tag)]
+			if !ok {
+				return nil, errors.New("unknown part-of-speech tag: " + strings.TrimSpace(tag))
+			}
+			expr.WriteString(regexp.QuoteMeta(string(letter)))
+		}
+		expr.WriteByte(']')
+		expr.WriteString(match[3])
+
+		rest = strings.TrimSpace(rest[len(match[0]):])
+	}
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, err
+	}
+	return &POSPattern{Source: source, re: re}, nil
+}
+
+// finds every phrase in a tagged sentence that matches the pattern
+func (p *POSPattern) findPhrases(sentence []TaggedWord) [][]string {
+	letters := make([]byte, len(sentence))
+	for i, tw := range sentence {
+		letter, ok := posTagLetters[tw.Tag]
+		if !ok {
+			letter = posTagLetters["X"]
+		}
+		letters[i] = letter
+	}
+
+	var phrases [][]string
+	for _, loc := range p.re.FindAllIndex(letters, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		phrase := make([]string, 0, loc[1]-loc[0])
+		for _, tw := range sentence[loc[0]:loc[1]] {
+			phrase = append(phrase, tw.Word)
+		}
+		phrases = append(phrases, phrase)
+	}
+	return phrases
+}
+
+// tags content and returns every word that appears inside a phrase matching the pattern
+// words are split with wordSplitter and lowercased so they line up with the keys of a TermCountIndex
+func posCandidateWords(content string, pattern *POSPattern, wordSplitter *regexp.Regexp) map[string]struct{} {
+	candidates := make(map[string]struct{})
+
+	for _, sentence := range tagText(content) {
+		for _, phrase := range pattern.findPhrases(sentence) {
+			for _, word := range phrase {
+				for _, part := range wordSplitter.Split(strings.ToLower(word), -1) {
+					if part != "" {
+						candidates[part] = struct{}{}
+					}
+				}
+			}
+		}
+	}
+
+	return candidates
+}
+
+// removes terms from tfi unless every word in them was seen inside a matching phrase
+func filterPOSCandidates(tfi TermFrequencyIndex, candidates map[string]struct{}) {
+	for term := range tfi {
+		for _, word := range strings.Fields(term) {
+			if _, ok := candidates[word]; !ok {
+				delete(tfi, term)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// TaggedWord is a word along with its universal part-of-speech tag such as NOUN, VERB or ADJ
+type TaggedWord struct {
+	Word string
+	Tag  string
+}
+
+// posTagger is an averaged perceptron part-of-speech tagger
+// weights maps each feature to a weight for every tag, tagdict holds words that only ever take one tag
+type posTagger struct {
+	weights map[string]map[string]float64
+	tags    []string
+	tagdict map[string]string
+}
+
+// the pretrained perceptron weights, built into the binary so the tagger needs no data files
+// train-tagger writes this file, see pos-train.go
+//
+//go:embed data/pos-weights.txt
+var posWeightsData string
+
+// the tag dictionary of words with a single tag, these skip the perceptron
+//
+//go:embed data/pos-lexicon.txt
+var posLexiconData string
+
+var (
+	defaultTagger     *posTagger
+	defaultTaggerOnce sync.Once
+)
+
+// splits text into words for tagging, keeping hyphenated words and contractions whole and
+// punctuation as separate tokens
+var taggerSplitter = regexp.MustCompile(`[\p{L}\p{N}]+(?:[-'’][\p{L}\p{N}]+)*|[^\s\p{L}\p{N}]`)
+
+// returns the tagger using the embedded weights and tag dictionary, reading them on first use
+func getDefaultTagger() *posTagger {
+	defaultTaggerOnce.Do(func() {
+		tagdict, err := parsePOSLexicon(posLexiconData)
+		if err != nil {
+			panic("invalid embedded part-of-speech lexicon: " + err.Error())
+		}
+		weights, tags, err := parsePOSWeights(posWeightsData)
+		if err != nil {
+			panic("invalid embedded part-of-speech weights: " + err.Error())
+		}
+		defaultTagger = &posTagger{weights: weights, tags: tags, tagdict: tagdict}
+	})
+	return defaultTagger
+}
+
+// reads a tag dictionary written one word per line followed by its tag,
+// skipping blank lines and # comments
+func parsePOSLexicon(data string) (map[string]string, error) {
+	tagdict := make(map[string]string)
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, errors.New("expected a word and its tag: " + line)
+		}
+		if _, ok := posTagLetters[fields[1]]; !ok {
+			return nil, errors.New("unknown part-of-speech tag: " + fields[1])
+		}
+		tagdict[normalizeTagWord(fields[0])] = fields[1]
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return tagdict, nil
+}
+
+// reads perceptron weights written one feature per line, a tab, then space separated TAG:weight pairs,
+// skipping blank lines and # comments, and returns them with the sorted tags they use
+func parsePOSWeights(data string) (map[string]map[string]float64, []string, error) {
+	weights := make(map[string]map[string]float64)
+	seenTags := make(map[string]struct{})
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		feature, pairs, ok := strings.Cut(line, "\t")
+		if !ok || feature == "" {
+			return nil, nil, errors.New("expected a feature, a tab and its weights: " + line)
+		}
+		tagWeights := make(map[string]float64)
+		for _, pair := range strings.Fields(pairs) {
+			tag, value, ok := strings.Cut(pair, ":")
+			if !ok {
+				return nil, nil, errors.New("malformed TAG:weight pair: " + pair)
+			}
+			if _, ok := posTagLetters[tag]; !ok {
+				return nil, nil, errors.New("unknown part-of-speech tag: " + tag)
+			}
+			w, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, nil, errors.New("invalid weight in " + pair)
+			}
+			tagWeights[tag] = w
+			seenTags[tag] = struct{}{}
+		}
+		weights[feature] = tagWeights
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(seenTags) == 0 {
+		return nil, nil, errors.New("no weights found")
+	}
+
+	tags := make([]string, 0, len(seenTags))
+	for tag := range seenTags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return weights, tags, nil
+}
+
+// scores every tag for the features and returns the best, ties go to the first tag alphabetically
+func (pt *posTagger) predict(features []string) string {
+	scores := make(map[string]float64, len(pt.tags))
+	for _, feature := range features {
+		for tag, w := range pt.weights[feature] {
+			scores[tag] += w
+		}
+	}
+
+	best := pt.tags[0]
+	for _, tag := range pt.tags[1:] {
+		if scores[tag] > scores[best] {
+			best = tag
+		}
+	}
+	return best
+}
+
+// tags a sentence of words from left to right, each prediction seeing the two tags before it
+func (pt *posTagger) tag(words []string) []TaggedWord {
+	tagged := make([]TaggedWord, len(words))
+
+	prev, prev2 := "-START-", "-START2-"
+	for i, word := range words {
+		tag, known := pt.lookup(word)
+		if !known {
+			tag = pt.predict(posFeatures(words, i, prev, prev2))
+		}
+		tagged[i] = TaggedWord{Word: word, Tag: tag}
+		prev2, prev = prev, tag
+	}
+
+	return tagged
+}
+
+// returns the tag for words that do not need the perceptron: punctuation, plain numbers and
+// words in the tag dictionary
+func (pt *posTagger) lookup(word string) (string, bool) {
+	if strings.IndexFunc(word, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) == -1 {
+		return "PUNCT", true
+	}
+	if strings.IndexFunc(word, func(r rune) bool { return !unicode.IsDigit(r) && r != '.' && r != ',' }) == -1 {
+		return "NUM", true
+	}
+	tag, ok := pt.tagdict[normalizeTagWord(word)]
+	return tag, ok
+}
+
+// lowercases a word, writes curly apostrophes as straight ones so "won’t" matches "won't",
+// and collapses numbers so they share features
+func normalizeTagWord(word string) string {
+	switch {
+	case len(word) == 4 && strings.IndexFunc(word, func(r rune) bool { return !unicode.IsDigit(r) }) == -1:
+		return "!YEAR"
+	case word != "" && unicode.IsDigit([]rune(word)[0]):
+		return "!DIGITS"
+	}
+	return strings.ReplaceAll(strings.ToLower(word), "’", "'")
+}
+
+// builds the perceptron features for the word at index i given the two previous tags
+func posFeatures(words []string, i int, prev, prev2 string) []string {
+	context := func(j int) string {
+		switch {
+		case j < 0:
+			return "-START-"
+		case j >= len(words):
+			return "-END-"
+		}
+		return normalizeTagWord(words[j])
+	}
+	suffix := func(word string, n int) string {
+		runes := []rune(word)
+		if len(runes) > n {
+			runes = runes[len(runes)-n:]
+		}
+		return string(runes)
+	}
+
+	word := context(i)
+	features := []string{
+		"bias",
+		"i suffix " + suffix(word, 3),
+		"i suffix2 " + suffix(word, 2),
+		"i pref1 " + string([]rune(word)[:1]),
+		"i-1 tag " + prev,
+		"i-2 tag " + prev2,
+		"i tag+i-2 tag " + prev + " " + prev2,
+		"i word " + word,
+		"i-1 tag+i word " + prev + " " + word,
+		"i-1 word " + context(i-1),
+		"i-1 suffix " + suffix(context(i-1), 3),
+		"i-2 word " + context(i-2),
+		"i+1 word " + context(i+1),
+		"i+1 suffix " + suffix(context(i+1), 3),
+		"i+2 word " + context(i+2),
+	}
+
+	// word shape helps with words never seen in training
+	if original := []rune(words[i]); unicode.IsUpper(original[0]) {
+		features = append(features, "i capitalised")
+	}
+	if strings.Contains(word, "-") {
+		features = append(features, "i hyphen")
+	}
+
+	return features
+}
+
+// tagText splits content into sentences and tags every word
+func tagText(content string) [][]TaggedWord {
+	tagger := getDefaultTagger()
+
+	var tagged [][]TaggedWord
+	for _, paragraph := range segmentText(content) {
+		for _, sentence := range paragraph.Sentences {
+			if words := taggerSplitter.FindAllString(sentence.Text, -1); len(words) > 0 {
+				tagged = append(tagged, tagger.tag(words))
+			}
+		}
+	}
+	return tagged
+}
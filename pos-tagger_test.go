@@ -0,0 +1,205 @@
+package main
+
+import (
+	"testing"
+)
+
+/*
+This file tests for:
+- parsing the tag dictionary and rejecting bad lines
+- parsing perceptron weights and rejecting bad lines
+- punctuation, numbers and tag dictionary words are tagged without the perceptron
+- tagging words with several tags from the words around them
+- tagging words the training corpus never saw from their shape
+- the default tagger on sentences it has not seen
+- compiling part-of-speech patterns and rejecting bad ones
+- finding noun phrases in a tagged sentence
+- filtering candidates in the extraction pipeline
+- benchmark for tagging text
+*/
+func TestParsePOSLexicon(t *testing.T) {
+	tagdict, err := parsePOSLexicon("# comment\nthe DET\n\nThey PRON\n")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(tagdict) != 2 || tagdict["they"] != "PRON" {
+		t.Errorf("Expected 2 words with they lowercased and tagged PRON, got %v", tagdict)
+	}
+
+	for _, data := range []string{"the", "the DETERMINER", "use VERB NOUN"} {
+		if _, err := parsePOSLexicon(data); err == nil {
+			t.Errorf("Expected error for %q, got nil", data)
+		}
+	}
+}
+
+func TestParsePOSWeights(t *testing.T) {
+	weights, tags, err := parsePOSWeights("# comment\nbias\tNOUN:1.5 VERB:-0.25\n\ni word the\tDET:2\n")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(weights) != 2 || weights["bias"]["VERB"] != -0.25 || weights["i word the"]["DET"] != 2 {
+		t.Errorf("Expected 2 features with their weights, got %v", weights)
+	}
+	if len(tags) != 3 || tags[0] != "DET" || tags[2] != "VERB" {
+		t.Errorf("Expected tags DET, NOUN and VERB, got %v", tags)
+	}
+
+	for _, data := range []string{"", "bias NOUN:1", "bias\tNOUN", "bias\tNOUNS:1", "bias\tNOUN:heavy"} {
+		if _, _, err := parsePOSWeights(data); err == nil {
+			t.Errorf("Expected error for %q, got nil", data)
+		}
+	}
+}
+
+func TestPOSTagger(t *testing.T) {
+	tagger := getDefaultTagger()
+
+	// tags a sentence and checks the tag of each word given
+	check := func(t *testing.T, sentence string, expected map[string]string) {
+		t.Helper()
+		tags := make(map[string]string)
+		for _, tw := range tagger.tag(taggerSplitter.FindAllString(sentence, -1)) {
+			tags[tw.Word] = tw.Tag
+		}
+		for word, tag := range expected {
+			if tags[word] != tag {
+				t.Errorf("%s: expected '%s' tagged %s, got %s", sentence, word, tag, tags[word])
+			}
+		}
+	}
+
+	// Test punctuation, numbers and tag dictionary words skip the perceptron
+	t.Run("Lookup", func(t *testing.T) {
+		for word, expected := range map[string]string{"(": "PUNCT", "–": "PUNCT", "2010": "NUM", "5,400": "NUM", "The": "DET"} {
+			if tag, ok := tagger.lookup(word); !ok || tag != expected {
+				t.Errorf("Expected '%s' tagged %s, got %s", word, expected, tag)
+			}
+		}
+		if _, ok := tagger.lookup("use"); ok {
+			t.Error("Expected 'use' to need context")
+		}
+	})
+
+	// Test words with several tags are tagged from their context
+	t.Run("Context", func(t *testing.T) {
+		check(t, "The test uses a new design.", map[string]string{"test": "NOUN", "uses": "VERB", "design": "NOUN"})
+		check(t, "They test every design to find its uses.", map[string]string{"test": "VERB", "design": "NOUN", "uses": "NOUN"})
+		check(t, "Most platforms can run it.", map[string]string{"Most": "ADJ", "run": "VERB"})
+		check(t, "I won’t just forget the dessert.", map[string]string{"won’t": "VERB", "forget": "VERB", "dessert": "NOUN"})
+	})
+
+	// Test words the training corpus never saw are tagged from their suffix and capitalisation
+	t.Run("UnseenWords", func(t *testing.T) {
+		check(t, "Rust quietly guarantees thread safety.", map[string]string{
+			"quietly": "ADV", "safety": "NOUN",
+		})
+		check(t, "Erlang offers scalable concurrency and distribution.", map[string]string{
+			"Erlang": "NOUN", "scalable": "ADJ", "distribution": "NOUN",
+		})
+		check(t, "Engineers benchmarked the compiled binaries.", map[string]string{
+			"benchmarked": "VERB", "compiled": "ADJ", "binaries": "NOUN",
+		})
+	})
+
+	// Test the default tagger on sentences that are not in its training corpus
+	t.Run("DefaultTagger", func(t *testing.T) {
+		tagged := tagText("OCaml was developed at INRIA. The garbage collector is generational. " +
+			"Developers rely on pattern matching and mutable references.")
+
+		tags := make(map[string]string)
+		for _, sentence := range tagged {
+			for _, tw := range sentence {
+				tags[tw.Word] = tw.Tag
+			}
+		}
+
+		expected := map[string]string{
+			"OCaml":        "NOUN",
+			"developed":    "VERB",
+			"INRIA":        "NOUN",
+			"garbage":      "NOUN",
+			"collector":    "NOUN",
+			"generational": "ADJ",
+			"matching":     "NOUN",
+			"mutable":      "ADJ",
+			"references":   "NOUN",
+		}
+		for word, tag := range expected {
+			if tags[word] != tag {
+				t.Errorf("Expected '%s' tagged %s, got %s", word, tag, tags[word])
+			}
+		}
+	})
+}
+
+func TestPOSPattern(t *testing.T) {
+	// Test valid patterns compile and bad ones are rejected
+	t.Run("Compile", func(t *testing.T) {
+		for _, source := range []string{"(ADJ)*(NOUN)+", "(ADJ|NOUN)* NOUN", "NOUN ADP? NOUN"} {
+			if _, err := compilePOSPattern(source); err != nil {
+				t.Errorf("Expected '%s' to compile, got: %v", source, err)
+			}
+		}
+		for _, source := range []string{"", "(ADJ)*(NOUNS)+", "(ADJ", "NOUN{2}"} {
+			if _, err := compilePOSPattern(source); err == nil {
+				t.Errorf("Expected error for '%s', got nil", source)
+			}
+		}
+	})
+
+	// Test noun phrases are found
+	t.Run("FindPhrases", func(t *testing.T) {
+		pattern, _ := compilePOSPattern("(ADJ)*(NOUN)+")
+		sentence := []TaggedWord{
+			{"Haskell", "NOUN"}, {"has", "VERB"}, {"a", "DET"}, {"strong", "ADJ"},
+			{"static", "ADJ"}, {"type", "NOUN"}, {"system", "NOUN"}, {".", "PUNCT"},
+		}
+
+		phrases := pattern.findPhrases(sentence)
+		if len(phrases) != 2 {
+			t.Fatalf("Expected 2 phrases, got %v", phrases)
+		}
+		if len(phrases[1]) != 4 || phrases[1][0] != "strong" || phrases[1][3] != "system" {
+			t.Errorf("Expected 'strong static type system', got %v", phrases[1])
+		}
+	})
+}
+
+func TestExtractKeywordsWithPOSPattern(t *testing.T) {
+	content := "Haskell is used in academia. Haskell is used in industry. Libraries are used widely."
+	pattern, err := compilePOSPattern("(ADJ)*(NOUN)+")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	keywords, err := extractKeywords(content, ExtractOptions{
+		NumKeywords: 10,
+		Stopwords:   map[string]struct{}{},
+		POSPattern:  pattern,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	for _, kw := range keywords {
+		if kw.Term == "used" {
+			t.Error("Expected verb 'used' to be filtered out")
+		}
+	}
+	if keywords[0].Term != "haskell" {
+		t.Errorf("Expected 'haskell' first, got %q", keywords[0].Term)
+	}
+}
+
+// Benchmark test for tagText function
+func BenchmarkTagText(b *testing.B) {
+	content := "Haskell has a strong, static type system based on type inference. " +
+		"Its principal innovation is type classes, originally conceived as a way to add overloading."
+	getDefaultTagger()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tagText(content)
+	}
+}
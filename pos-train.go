@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// number of passes made over the training sentences
+const posTrainingIterations = 8
+
+// pennTreebankTags maps Penn Treebank tags onto the universal tagset the tagger uses
+var pennTreebankTags = map[string]string{
+	"NN": "NOUN", "NNS": "NOUN", "NNP": "NOUN", "NNPS": "NOUN",
+	"VB": "VERB", "VBD": "VERB", "VBG": "VERB", "VBN": "VERB", "VBP": "VERB", "VBZ": "VERB", "MD": "VERB",
+	"JJ": "ADJ", "JJR": "ADJ", "JJS": "ADJ",
+	"RB": "ADV", "RBR": "ADV", "RBS": "ADV", "WRB": "ADV",
+	"PRP": "PRON", "PRP$": "PRON", "WP": "PRON", "WP$": "PRON",
+	"DT": "DET", "PDT": "DET", "WDT": "DET", "EX": "DET",
+	"IN": "ADP", "CD": "NUM", "CC": "CONJ",
+	"POS": "PRT", "RP": "PRT", "TO": "PRT",
+	".": "PUNCT", ",": "PUNCT", ":": "PUNCT", "``": "PUNCT", "''": "PUNCT", "$": "PUNCT", "#": "PUNCT",
+	"-LRB-": "PUNCT", "-RRB-": "PUNCT", "-LCB-": "PUNCT", "-RCB-": "PUNCT",
+	"FW": "X", "LS": "X", "SYM": "X", "UH": "X",
+}
+
+// pennTreebankEscapes maps the words treebanks write in place of brackets and quotes back to the characters
+var pennTreebankEscapes = map[string]string{
+	"-LRB-": "(", "-RRB-": ")", "-LCB-": "{", "-RCB-": "}", "``": "\"", "''": "\"",
+}
+
+func runTrainTagger(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("train-tagger", flag.ContinueOnError)
+	corpusPath := flags.String("corpus", "", "tagged sentences, one per line as word/TAG pairs with universal or Penn Treebank tags")
+	lexiconPath := flags.String("lexicon", "", "tag dictionary of words that skip the perceptron, defaults to the built in one")
+	outPath := flags.String("out", "", "file to write the weights to, defaults to standard output")
+	iterations := flags.Int("iterations", posTrainingIterations, "number of passes over the corpus")
+	minWeight := flags.Float64("min-weight", 0.01, "drop weights smaller than this to keep the file small")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *corpusPath == "" {
+		return errors.New("train-tagger needs a -corpus")
+	}
+	if *iterations < 1 {
+		return errors.New("iterations must be at least 1")
+	}
+
+	corpus, err := os.ReadFile(*corpusPath)
+	if err != nil {
+		return err
+	}
+	sentences, err := parseTaggedSentences(string(corpus))
+	if err != nil {
+		return err
+	}
+	if len(sentences) == 0 {
+		return errors.New("no tagged sentences in " + *corpusPath)
+	}
+
+	lexicon := posLexiconData
+	if *lexiconPath != "" {
+		data, err := os.ReadFile(*lexiconPath)
+		if err != nil {
+			return err
+		}
+		lexicon = string(data)
+	}
+	tagdict, err := parsePOSLexicon(lexicon)
+	if err != nil {
+		return err
+	}
+
+	tagger := trainPOSTagger(sentences, tagdict, *iterations)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "# averaged perceptron weights for the part-of-speech tagger, written by train-tagger\n")
+	fmt.Fprintf(&out, "# trained for %d iterations on %d sentences from %s\n", *iterations, len(sentences), filepath.Base(*corpusPath))
+	fmt.Fprintf(&out, "# one feature per line, a tab, then space separated TAG:weight pairs\n")
+	writePOSWeights(&out, tagger.weights, *minWeight)
+
+	if *outPath == "" {
+		_, err := io.WriteString(stdout, out.String())
+		return err
+	}
+	return writeFileAtomic(*outPath, []byte(out.String()), 0o644)
+}
+
+// reads sentences written one per line as word/TAG pairs, skipping blank lines and # comments
+// Penn Treebank tags are mapped onto the universal tagset, empty -NONE- elements are dropped and
+// split off clitics are joined back on, so "do n't" is read as the single word "don't" like tagText sees it
+func parseTaggedSentences(data string) ([][]TaggedWord, error) {
+	var sentences [][]TaggedWord
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var sentence []TaggedWord
+		for _, pair := range strings.Fields(line) {
+			slash := strings.LastIndex(pair, "/")
+			if slash <= 0 || slash == len(pair)-1 {
+				return nil, errors.New("malformed word/TAG pair: " + pair)
+			}
+			word, tag := pair[:slash], pair[slash+1:]
+			if tag == "-NONE-" {
+				continue
+			}
+			if escaped, ok := pennTreebankEscapes[word]; ok {
+				word = escaped
+			}
+
+			universal, err := universalTag(tag)
+			if err != nil {
+				return nil, err
+			}
+			if n := len(sentence); n > 0 && isClitic(word) {
+				sentence[n-1].Word += word
+				continue
+			}
+			sentence = append(sentence, TaggedWord{Word: word, Tag: universal})
+		}
+		if len(sentence) > 0 {
+			sentences = append(sentences, sentence)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return sentences, nil
+}
+
+// returns the universal tag for a universal or Penn Treebank tag, ambiguous tags such as "NN|VBG" take their first
+func universalTag(tag string) (string, error) {
+	if first, _, ok := strings.Cut(tag, "|"); ok && first != "" {
+		tag = first
+	}
+	if _, ok := posTagLetters[tag]; ok {
+		return tag, nil
+	}
+	if universal, ok := pennTreebankTags[tag]; ok {
+		return universal, nil
+	}
+	return "", errors.New("unknown part-of-speech tag: " + tag)
+}
+
+// reports whether a treebank word was split off the word before it, such as "'s" or "n't"
+func isClitic(word string) bool {
+	switch strings.ToLower(word) {
+	case "n't", "'s", "'re", "'ve", "'ll", "'d", "'m":
+		return true
+	}
+	return false
+}
+
+// trains a tagger on tagged sentences, averaging the weights over every update
+// words in tagdict are tagged from it, so the perceptron only learns the others
+func trainPOSTagger(sentences [][]TaggedWord, tagdict map[string]string, iterations int) *posTagger {
+	tagger := &posTagger{
+		weights: make(map[string]map[string]float64),
+		tagdict: tagdict,
+	}
+
+	// collect the tagset
+	seenTags := make(map[string]struct{})
+	for _, sentence := range sentences {
+		for _, tw := range sentence {
+			seenTags[tw.Tag] = struct{}{}
+		}
+	}
+	for tag := range seenTags {
+		tagger.tags = append(tagger.tags, tag)
+	}
+	sort.Strings(tagger.tags)
+
+	// running totals for averaging, stamps record the update count when a weight last changed
+	totals := make(map[string]map[string]float64)
+	stamps := make(map[string]map[string]int)
+	updates := 0
+
+	update := func(truth, guess string, features []string) {
+		updates++
+		if truth == guess {
+			return
+		}
+		for _, feature := range features {
+			if tagger.weights[feature] == nil {
+				tagger.weights[feature] = make(map[string]float64)
+				totals[feature] = make(map[string]float64)
+				stamps[feature] = make(map[string]int)
+			}
+			for tag, delta := range map[string]float64{truth: 1, guess: -1} {
+				w := tagger.weights[feature][tag]
+				totals[feature][tag] += float64(updates-stamps[feature][tag]) * w
+				stamps[feature][tag] = updates
+				tagger.weights[feature][tag] = w + delta
+			}
+		}
+	}
+
+	// a fixed seed keeps training, and so the written weights, the same between runs
+	random := rand.New(rand.NewSource(1))
+	order := make([]int, len(sentences))
+	for i := range order {
+		order[i] = i
+	}
+
+	for iter := 0; iter < iterations; iter++ {
+		for _, s := range order {
+			sentence := sentences[s]
+			words := make([]string, len(sentence))
+			for i, tw := range sentence {
+				words[i] = tw.Word
+			}
+
+			prev, prev2 := "-START-", "-START2-"
+			for i, tw := range sentence {
+				guess, known := tagger.lookup(tw.Word)
+				if !known {
+					features := posFeatures(words, i, prev, prev2)
+					guess = tagger.predict(features)
+					update(tw.Tag, guess, features)
+				}
+				prev2, prev = prev, guess
+			}
+		}
+		random.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+	}
+
+	// replace each weight with its average over all updates
+	for feature, tagWeights := range tagger.weights {
+		for tag, w := range tagWeights {
+			total := totals[feature][tag] + float64(updates-stamps[feature][tag])*w
+			if avg := total / float64(updates); avg != 0 {
+				tagWeights[tag] = avg
+			} else {
+				delete(tagWeights, tag)
+			}
+		}
+	}
+
+	return tagger
+}
+
+// writes the weights one feature per line in sorted order, dropping weights smaller than minWeight
+// and features left with none
+func writePOSWeights(w io.Writer, weights map[string]map[string]float64, minWeight float64) {
+	features := make([]string, 0, len(weights))
+	for feature := range weights {
+		features = append(features, feature)
+	}
+	sort.Strings(features)
+
+	for _, feature := range features {
+		tags := make([]string, 0, len(weights[feature]))
+		for tag, weight := range weights[feature] {
+			if math.Abs(weight) >= minWeight {
+				tags = append(tags, tag)
+			}
+		}
+		if len(tags) == 0 {
+			continue
+		}
+		sort.Strings(tags)
+
+		pairs := make([]string, len(tags))
+		for i, tag := range tags {
+			pairs[i] = tag + ":" + strconv.FormatFloat(weights[feature][tag], 'f', 3, 64)
+		}
+		fmt.Fprintf(w, "%s\t%s\n", feature, strings.Join(pairs, " "))
+	}
+}
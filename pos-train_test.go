@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/*
+This file tests for:
+- reading tagged sentences with universal and Penn Treebank tags
+- dropping empty elements and joining split off clitics
+- rejecting malformed pairs and unknown tags
+- training a tagger that tags its training sentences
+- writing weights that read back, dropping small ones
+- the train-tagger command writing a weights file
+*/
+func TestParseTaggedSentences(t *testing.T) {
+	data := "# comment\n" +
+		"The/DT compiler/NN is/VBZ n't/RB fast/JJ */-NONE- ./.\n" +
+		"\n" +
+		"-LRB-/-LRB- Haskell/NNP 's/POS types/NNS -RRB-/-RRB-\n" +
+		"It/PRON works/VERB\n"
+
+	sentences, err := parseTaggedSentences(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(sentences) != 3 {
+		t.Fatalf("Expected 3 sentences, got %d", len(sentences))
+	}
+
+	expected := [][]TaggedWord{
+		{{"The", "DET"}, {"compiler", "NOUN"}, {"isn't", "VERB"}, {"fast", "ADJ"}, {".", "PUNCT"}},
+		{{"(", "PUNCT"}, {"Haskell's", "NOUN"}, {"types", "NOUN"}, {")", "PUNCT"}},
+		{{"It", "PRON"}, {"works", "VERB"}},
+	}
+	for i, sentence := range sentences {
+		if len(sentence) != len(expected[i]) {
+			t.Errorf("Expected %v, got %v", expected[i], sentence)
+			continue
+		}
+		for j, tw := range sentence {
+			if tw != expected[i][j] {
+				t.Errorf("Expected %v, got %v", expected[i][j], tw)
+			}
+		}
+	}
+
+	for _, data := range []string{"word", "word/", "/NN", "word/NOUNS", "word/XYZ"} {
+		if _, err := parseTaggedSentences(data); err == nil {
+			t.Errorf("Expected error for %q, got nil", data)
+		}
+	}
+}
+
+func TestTrainPOSTagger(t *testing.T) {
+	sentences, err := parseTaggedSentences(
+		"the/DET dog/NOUN runs/VERB fast/ADV\n" +
+			"a/DET cat/NOUN sleeps/VERB quietly/ADV\n" +
+			"the/DET bird/NOUN sings/VERB loudly/ADV\n" +
+			"runs/NOUN are/VERB good/ADJ\n")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	tagger := trainPOSTagger(sentences, map[string]string{"the": "DET", "a": "DET"}, posTrainingIterations)
+	if len(tagger.tags) != 5 {
+		t.Errorf("Expected 5 tags, got %v", tagger.tags)
+	}
+
+	// every training sentence should be tagged as it was given, including both uses of "runs"
+	for _, sentence := range sentences {
+		words := make([]string, len(sentence))
+		for i, tw := range sentence {
+			words[i] = tw.Word
+		}
+		for i, tw := range tagger.tag(words) {
+			if tw.Tag != sentence[i].Tag {
+				t.Errorf("Expected '%s' tagged %s, got %s", tw.Word, sentence[i].Tag, tw.Tag)
+			}
+		}
+	}
+}
+
+func TestWritePOSWeights(t *testing.T) {
+	weights := map[string]map[string]float64{
+		"bias":        {"NOUN": 1.23456, "VERB": -0.5, "ADJ": 0.001},
+		"i word tiny": {"ADV": 0.002},
+	}
+
+	var out bytes.Buffer
+	writePOSWeights(&out, weights, 0.01)
+	if out.String() != "bias\tNOUN:1.235 VERB:-0.500\n" {
+		t.Errorf("Expected only the large bias weights, got %q", out.String())
+	}
+
+	read, tags, err := parsePOSWeights(out.String())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(read) != 1 || read["bias"]["NOUN"] != 1.235 || len(tags) != 2 {
+		t.Errorf("Expected the written weights back, got %v with tags %v", read, tags)
+	}
+}
+
+func TestRunTrainTagger(t *testing.T) {
+	dir := t.TempDir()
+	corpus := filepath.Join(dir, "corpus.txt")
+	if err := os.WriteFile(corpus, []byte("Compilers/NNS check/VBP types/NNS ./.\nThey/PRP run/VBP programs/NNS ./.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Test the weights are written to a file the tagger can read
+	weightsPath := filepath.Join(dir, "weights.txt")
+	var out bytes.Buffer
+	if err := runTrainTagger([]string{"-corpus", corpus, "-out", weightsPath, "-iterations", "2"}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	data, err := os.ReadFile(weightsPath)
+	if err != nil {
+		t.Fatalf("Expected weights file, got: %v", err)
+	}
+	if !strings.Contains(string(data), "on 2 sentences from corpus.txt") {
+		t.Errorf("Expected header naming the corpus, got %q", strings.SplitN(string(data), "\n", 3)[1])
+	}
+	if _, _, err := parsePOSWeights(string(data)); err != nil {
+		t.Errorf("Expected readable weights, got: %v", err)
+	}
+
+	// Test a missing corpus, an empty corpus and bad iterations are rejected
+	empty := filepath.Join(dir, "empty.txt")
+	if err := os.WriteFile(empty, []byte("# nothing\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{}, {"-corpus", empty}, {"-corpus", corpus, "-iterations", "0"}} {
+		if err := runTrainTagger(args, &out); err == nil {
+			t.Errorf("Expected error for %v, got nil", args)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// DecayCurve maps the relative position of a term's first occurrence, from 0 at the start of
+// the document to 1 at the end, onto a weight multiplier
+type DecayCurve func(position float64) float64
+
+// PositionWeighting boosts terms that are introduced early in a document
+// a nil FirstOccurrence turns off decay and a FirstParagraphBoost of 0 or 1 turns off the boost
+// a FirstParagraphBoost between 0 and 1 lowers first paragraph terms instead, config files reject it
+type PositionWeighting struct {
+	FirstOccurrence     DecayCurve
+	FirstParagraphBoost float64
+}
+
+// linearDecay falls in a straight line from 1 at the start of the document to floor at the end
+func linearDecay(floor float64) DecayCurve {
+	return func(position float64) float64 {
+		return 1 - (1-floor)*position
+	}
+}
+
+// exponentialDecay falls quickly at first, a rate of 0 gives every position a weight of 1
+func exponentialDecay(rate float64) DecayCurve {
+	return func(position float64) float64 {
+		return math.Exp(-rate * position)
+	}
+}
+
+// inverseDecay falls as 1 / (1 + scale * position), flattening out towards the end of the document
+func inverseDecay(scale float64) DecayCurve {
+	return func(position float64) float64 {
+		return 1 / (1 + scale*position)
+	}
+}
+
+// newDecayCurve returns the named curve using param as its floor, rate or scale
+func newDecayCurve(name string, param float64) (DecayCurve, error) {
+	switch name {
+	case "linear":
+		if param < 0 || param > 1 {
+			return nil, errors.New("linear decay floor must be between 0 and 1")
+		}
+		return linearDecay(param), nil
+	case "exponential":
+		if param < 0 {
+			return nil, errors.New("exponential decay rate must not be negative")
+		}
+		return exponentialDecay(param), nil
+	case "inverse":
+		if param < 0 {
+			return nil, errors.New("inverse decay scale must not be negative")
+		}
+		return inverseDecay(param), nil
+	}
+	return nil, errors.New("unknown decay curve: " + name)
+}
+
+// Multiplies each term's frequency by weights based on where it first appears in the content
+// Returns a new TermFrequencyIndex and leaves tfi untouched
+func applyPositionWeights(content string, wordSplitter *regexp.Regexp, tfi TermFrequencyIndex, pw PositionWeighting) TermFrequencyIndex {
+	// find the index of the first occurrence of each word
+	words := wordSplitter.Split(strings.ToLower(content), -1)
+	firstSeen := make(map[string]int)
+	for i, word := range words {
+		if _, ok := firstSeen[word]; !ok {
+			firstSeen[word] = i
+		}
+	}
+
+	// find the words in the first paragraph
+	firstParagraph := make(map[string]struct{})
+	if paragraphs := segmentText(content); len(paragraphs) > 0 {
+		for _, word := range wordSplitter.Split(strings.ToLower(paragraphs[0].Text), -1) {
+			firstParagraph[word] = struct{}{}
+		}
+	}
+
+	weighted := make(TermFrequencyIndex, len(tfi))
+	for term, freq := range tfi {
+		// multi word terms such as collocations are placed by their first word
+		word, _, _ := strings.Cut(term, " ")
+
+		// decay each word by how far into the document it first appears
+		if index, ok := firstSeen[word]; ok && pw.FirstOccurrence != nil && len(words) > 1 {
+			freq *= pw.FirstOccurrence(float64(index) / float64(len(words)-1))
+		}
+
+		// boost words that appear in the first paragraph
+		if _, ok := firstParagraph[word]; ok && pw.FirstParagraphBoost > 0 {
+			freq *= pw.FirstParagraphBoost
+		}
+
+		weighted[term] = freq
+	}
+
+	return weighted
+}
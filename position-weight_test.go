@@ -0,0 +1,117 @@
+package main
+
+import (
+	"math"
+	"regexp"
+	"testing"
+)
+
+/*
+This file tests for:
+- the shape of each decay curve
+- building curves by name and rejecting bad parameters
+- first occurrence decay favours early terms
+- first paragraph boost
+- position weighting in the extraction pipeline
+*/
+func TestDecayCurves(t *testing.T) {
+	// Test each curve starts at 1 and falls towards the end
+	t.Run("CurveShapes", func(t *testing.T) {
+		curves := map[string]DecayCurve{
+			"linear":      linearDecay(0.5),
+			"exponential": exponentialDecay(2),
+			"inverse":     inverseDecay(3),
+		}
+		for name, curve := range curves {
+			if math.Abs(curve(0)-1) > 0.0001 {
+				t.Errorf("%s: expected weight 1 at the start, got %.4f", name, curve(0))
+			}
+			if curve(1) >= curve(0.5) || curve(0.5) >= curve(0) {
+				t.Errorf("%s: expected weights to fall with position", name)
+			}
+		}
+
+		if math.Abs(linearDecay(0.5)(1)-0.5) > 0.0001 {
+			t.Errorf("Expected linear decay to end at its floor, got %.4f", linearDecay(0.5)(1))
+		}
+	})
+
+	// Test curves can be built by name
+	t.Run("CurvesByName", func(t *testing.T) {
+		if _, err := newDecayCurve("exponential", 1.5); err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+		if _, err := newDecayCurve("linear", 2); err == nil {
+			t.Error("Expected error for linear floor above 1, got nil")
+		}
+		if _, err := newDecayCurve("cubic", 1); err == nil {
+			t.Error("Expected error for unknown curve, got nil")
+		}
+	})
+}
+
+func TestApplyPositionWeights(t *testing.T) {
+	wordSplitter := regexp.MustCompile(`\W+`)
+
+	// Test that of two equally frequent words the earlier one scores higher
+	t.Run("FirstOccurrenceDecay", func(t *testing.T) {
+		content := "haskell filler filler filler compiler"
+		tfi := TermFrequencyIndex{"haskell": 0.2, "compiler": 0.2}
+
+		result := applyPositionWeights(content, wordSplitter, tfi, PositionWeighting{FirstOccurrence: linearDecay(0)})
+
+		if math.Abs(result["haskell"]-0.2) > 0.0001 {
+			t.Errorf("Expected 'haskell' to keep its score, got %.4f", result["haskell"])
+		}
+		if result["compiler"] > 0.0001 {
+			t.Errorf("Expected 'compiler' at the end to decay to 0, got %.4f", result["compiler"])
+		}
+
+		// the input index is left alone
+		if tfi["compiler"] != 0.2 {
+			t.Error("Expected input TermFrequencyIndex to be unchanged")
+		}
+	})
+
+	// Test words in the first paragraph are boosted
+	t.Run("FirstParagraphBoost", func(t *testing.T) {
+		content := "haskell language\n\n compiler language"
+		tfi := TermFrequencyIndex{"haskell": 0.25, "compiler": 0.25, "language": 0.5}
+
+		result := applyPositionWeights(content, wordSplitter, tfi, PositionWeighting{FirstParagraphBoost: 2})
+
+		if math.Abs(result["haskell"]-0.5) > 0.0001 {
+			t.Errorf("Expected 'haskell' to be boosted to 0.5, got %.4f", result["haskell"])
+		}
+		if math.Abs(result["compiler"]-0.25) > 0.0001 {
+			t.Errorf("Expected 'compiler' to keep 0.25, got %.4f", result["compiler"])
+		}
+	})
+}
+
+func TestExtractKeywordsWithPosition(t *testing.T) {
+	stopwords := map[string]struct{}{"the": {}}
+	content := "Haskell is lazy.\n\n The compiler is fast. The compiler is big. Haskell again."
+
+	// without weighting compiler ranks first as it appears most often
+	plain, err := extractKeywords(content, ExtractOptions{NumKeywords: 1, Stopwords: stopwords})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if plain[0].Term != "compiler" {
+		t.Errorf("Expected 'compiler' without weighting, got %q", plain[0].Term)
+	}
+
+	// a strong first paragraph boost lifts haskell above it
+	weighted, err := extractKeywords(content, ExtractOptions{
+		NumKeywords: 1,
+		Stopwords:   stopwords,
+		Position:    &PositionWeighting{FirstParagraphBoost: 3},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if weighted[0].Term != "haskell" {
+		t.Errorf("Expected 'haskell' with weighting, got %q", weighted[0].Term)
+	}
+}
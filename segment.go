@@ -0,0 +1,179 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Sentence is a span of text along with its byte offsets into the original content
+type Sentence struct {
+	Text  string `json:"text"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// Paragraph is a block of text separated from its neighbours by blank lines
+// Start and End are byte offsets into the original content
+type Paragraph struct {
+	Text      string
+	Start     int
+	End       int
+	Sentences []Sentence
+}
+
+// words that end in a full stop without ending a sentence, stored lowercase without the final dot
+var abbreviations = map[string]struct{}{
+	"e.g": {}, "i.e": {}, "etc": {}, "vs": {}, "cf": {}, "al": {}, "approx": {},
+	"mr": {}, "mrs": {}, "ms": {}, "dr": {}, "prof": {}, "sr": {}, "jr": {}, "st": {},
+	"no": {}, "fig": {}, "figs": {}, "vol": {}, "pp": {}, "inc": {}, "ltd": {}, "co": {},
+	"jan": {}, "feb": {}, "mar": {}, "apr": {}, "jun": {}, "jul": {}, "aug": {},
+	"sep": {}, "sept": {}, "oct": {}, "nov": {}, "dec": {}, "u.s": {}, "u.k": {},
+}
+
+// matches blank lines, which separate paragraphs
+var paragraphBreak = regexp.MustCompile(`\n\s*\n`)
+
+// matches one or more citation markers directly after a sentence ending such as .[26][27]
+var trailingCitations = regexp.MustCompile(`^(?:\[[^\]\n]{1,20}\])+`)
+
+// segmentText splits content into paragraphs on blank lines and each paragraph into sentences
+func segmentText(content string) []Paragraph {
+	var paragraphs []Paragraph
+
+	start := 0
+	breaks := paragraphBreak.FindAllStringIndex(content, -1)
+	breaks = append(breaks, []int{len(content), len(content)})
+	for _, br := range breaks {
+		if text, offset := trimSpan(content, start, br[0]); text != "" {
+			paragraphs = append(paragraphs, Paragraph{
+				Text:      text,
+				Start:     offset,
+				End:       offset + len(text),
+				Sentences: segmentSentences(content, offset, offset+len(text)),
+			})
+		}
+		start = br[1]
+	}
+
+	return paragraphs
+}
+
+// splits content[start:end] into sentences, handling abbreviations, decimals and citation brackets
+func segmentSentences(content string, start, end int) []Sentence {
+	var sentences []Sentence
+
+	sentenceStart := start
+	for i := start; i < end; i++ {
+		c := content[i]
+		if c != '.' && c != '!' && c != '?' {
+			continue
+		}
+		if c == '.' && !endsSentence(content, i, end) {
+			continue
+		}
+
+		// take in any repeated punctuation, closing quotes and brackets, and citation markers
+		stop := i + 1
+		for stop < end {
+			r, size := utf8.DecodeRuneInString(content[stop:end])
+			if !strings.ContainsRune(".!?\"')”’", r) {
+				break
+			}
+			stop += size
+		}
+		stop += len(trailingCitations.FindString(content[stop:end]))
+
+		// a sentence only ends when followed by space and something that is not lowercase
+		if stop < end {
+			if !isSpaceByte(content[stop]) {
+				continue
+			}
+			next, _ := utf8.DecodeRuneInString(strings.TrimLeft(content[stop:end], " \t\r\n"))
+			if unicode.IsLower(next) {
+				continue
+			}
+		}
+
+		if text, offset := trimSpan(content, sentenceStart, stop); text != "" {
+			sentences = append(sentences, Sentence{Text: text, Start: offset, End: offset + len(text)})
+		}
+		sentenceStart = stop
+		i = stop - 1
+	}
+
+	// whatever is left over is the last sentence
+	if text, offset := trimSpan(content, sentenceStart, end); text != "" {
+		sentences = append(sentences, Sentence{Text: text, Start: offset, End: offset + len(text)})
+	}
+
+	return sentences
+}
+
+// checks whether the full stop at index i ends a sentence rather than a decimal, initial or abbreviation
+func endsSentence(content string, i, end int) bool {
+	// decimals such as 3.14
+	if i > 0 && i+1 < end && isDigitByte(content[i-1]) && isDigitByte(content[i+1]) {
+		return false
+	}
+
+	// find the word before the full stop
+	wordStart := i
+	for wordStart > 0 && !isSpaceByte(content[wordStart-1]) && content[wordStart-1] != '(' {
+		wordStart--
+	}
+	word := strings.ToLower(content[wordStart:i])
+
+	// single letter initials such as J. Smith
+	if utf8.RuneCountInString(word) == 1 && unicode.IsLetter([]rune(word)[0]) {
+		return false
+	}
+	_, abbreviation := abbreviations[word]
+	return !abbreviation
+}
+
+// trims whitespace from content[start:end] and returns the trimmed text and its new start offset
+func trimSpan(content string, start, end int) (string, int) {
+	span := content[start:end]
+	trimmed := strings.TrimLeftFunc(span, unicode.IsSpace)
+	start += len(span) - len(trimmed)
+	return strings.TrimRightFunc(trimmed, unicode.IsSpace), start
+}
+
+func isSpaceByte(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isDigitByte(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// finds every sentence containing the words of a keyword, in order, so a keyword can be traced back to where it came from
+// tokens are the lowercased words of the same content, a phrase only matches within one sentence
+func findKeywordSentences(paragraphs []Paragraph, tokens []Token, words []string) []Sentence {
+	var matches []Sentence
+
+	i := 0
+	for _, paragraph := range paragraphs {
+		for _, sentence := range paragraph.Sentences {
+			// the tokens of the sentence
+			for i < len(tokens) && tokens[i].Start < sentence.Start {
+				i++
+			}
+			end := i
+			for end < len(tokens) && tokens[end].End <= sentence.End {
+				end++
+			}
+			inside := tokens[i:end]
+			for j := range inside {
+				if matchesAt(inside, j, words) {
+					matches = append(matches, sentence)
+					break
+				}
+			}
+		}
+	}
+
+	return matches
+}
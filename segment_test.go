@@ -0,0 +1,167 @@
+package main
+
+import (
+	"testing"
+)
+
+/*
+This file tests for:
+- splitting paragraphs on blank lines with correct offsets
+- splitting sentences on full stops, question and exclamation marks
+- abbreviations, initials and decimals do not end sentences
+- citation brackets stay with the sentence they follow
+- tracing single and multi-word keywords back to their sentences
+- extraction listing the sentences of each keyword
+- benchmark for segmenting text
+*/
+func TestSegmentText(t *testing.T) {
+	// Test paragraphs and their offsets
+	t.Run("Paragraphs", func(t *testing.T) {
+		content := "  First paragraph.\nStill first.\n \n\nSecond paragraph.\n\n\n"
+		paragraphs := segmentText(content)
+
+		if len(paragraphs) != 2 {
+			t.Fatalf("Expected 2 paragraphs, got %d", len(paragraphs))
+		}
+		for _, p := range paragraphs {
+			if content[p.Start:p.End] != p.Text {
+				t.Errorf("Offsets [%d:%d] do not match paragraph text %q", p.Start, p.End, p.Text)
+			}
+		}
+		if paragraphs[1].Text != "Second paragraph." {
+			t.Errorf("Expected 'Second paragraph.', got %q", paragraphs[1].Text)
+		}
+	})
+
+	// Test basic sentence splitting and offsets
+	t.Run("Sentences", func(t *testing.T) {
+		content := "Is Haskell lazy? Yes! It is lazy by default."
+		sentences := segmentText(content)[0].Sentences
+
+		expected := []string{"Is Haskell lazy?", "Yes!", "It is lazy by default."}
+		if len(sentences) != len(expected) {
+			t.Fatalf("Expected %d sentences, got %d: %v", len(expected), len(sentences), sentences)
+		}
+		for i, s := range sentences {
+			if s.Text != expected[i] {
+				t.Errorf("Expected sentence %q, got %q", expected[i], s.Text)
+			}
+			if content[s.Start:s.End] != s.Text {
+				t.Errorf("Offsets [%d:%d] do not match sentence text %q", s.Start, s.End, s.Text)
+			}
+		}
+	})
+
+	// Test abbreviations, initials and decimals
+	t.Run("NonBoundaries", func(t *testing.T) {
+		content := "Features e.g. type classes were added by Dr. Wadler and P. Hudak in version 1.0 of the report. Then more came."
+		sentences := segmentText(content)[0].Sentences
+
+		if len(sentences) != 2 {
+			t.Fatalf("Expected 2 sentences, got %d: %v", len(sentences), sentences)
+		}
+		if sentences[1].Text != "Then more came." {
+			t.Errorf("Expected 'Then more came.', got %q", sentences[1].Text)
+		}
+	})
+
+	// Test citation brackets stay with their sentence
+	t.Run("Citations", func(t *testing.T) {
+		content := "Haskell has lazy evaluation.[26][27] Haskell pioneered type classes."
+		sentences := segmentText(content)[0].Sentences
+
+		if len(sentences) != 2 {
+			t.Fatalf("Expected 2 sentences, got %d: %v", len(sentences), sentences)
+		}
+		if sentences[0].Text != "Haskell has lazy evaluation.[26][27]" {
+			t.Errorf("Expected citations to stay with the first sentence, got %q", sentences[0].Text)
+		}
+	})
+
+	// Test empty content
+	t.Run("EmptyContent", func(t *testing.T) {
+		if paragraphs := segmentText(" \n\n "); len(paragraphs) != 0 {
+			t.Errorf("Expected no paragraphs, got %d", len(paragraphs))
+		}
+	})
+}
+
+func TestFindKeywordSentences(t *testing.T) {
+	content := "Haskell is lazy. GHC compiles Haskell.\n\nThe compiler is fast. Lazy evaluation helps."
+	paragraphs := segmentText(content)
+	tokens := tokenize(content, defaultWordSplitter)
+
+	matches := findKeywordSentences(paragraphs, tokens, []string{"lazy"})
+	if len(matches) != 2 {
+		t.Fatalf("Expected 2 sentences containing 'lazy', got %d", len(matches))
+	}
+	if matches[1].Text != "Lazy evaluation helps." || content[matches[1].Start:matches[1].End] != matches[1].Text {
+		t.Errorf("Expected 'Lazy evaluation helps.' at its offsets, got %+v", matches[1])
+	}
+
+	// whole words only
+	if matches := findKeywordSentences(paragraphs, tokens, []string{"compile"}); len(matches) != 0 {
+		t.Errorf("Expected no sentences for partial word 'compile', got %d", len(matches))
+	}
+
+	// Test multi-word keywords match their words in order within one sentence
+	t.Run("MultiWord", func(t *testing.T) {
+		content := "Haskell pioneered type classes. The Glasgow Haskell Compiler supports them.\n\n" +
+			"A type. Classes follow. Classes of type are not type classes either way."
+		paragraphs := segmentText(content)
+		tokens := tokenize(content, defaultWordSplitter)
+
+		matches := findKeywordSentences(paragraphs, tokens, []string{"type", "classes"})
+		if len(matches) != 2 || matches[0].Text != "Haskell pioneered type classes." {
+			t.Errorf("Expected 2 sentences with 'type classes', got %v", matches)
+		}
+		matches = findKeywordSentences(paragraphs, tokens, keywordWords("Glasgow Haskell Compiler", defaultWordSplitter))
+		if len(matches) != 1 || matches[0].Text != "The Glasgow Haskell Compiler supports them." {
+			t.Errorf("Expected 1 sentence with 'glasgow haskell compiler', got %v", matches)
+		}
+	})
+}
+
+func TestExtractKeywordSentences(t *testing.T) {
+	content := "Haskell pioneered type classes. Type classes add overloading.\n\nRust has traits. Type classes inspired traits."
+	keywords, err := extractKeywords(content, ExtractOptions{
+		NumKeywords:  3,
+		Stopwords:    map[string]struct{}{},
+		Collocations: &CollocationOptions{MaxN: 2, MinCount: 2, Measure: "tscore"},
+		Sentences:    true,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	found := false
+	for _, kw := range keywords {
+		if kw.Term != "type classes" {
+			continue
+		}
+		found = true
+		if len(kw.Sentences) != 3 || kw.Sentences[2].Text != "Type classes inspired traits." {
+			t.Errorf("Expected 3 sentences for 'type classes', got %v", kw.Sentences)
+		}
+	}
+	if !found {
+		t.Fatalf("Expected 'type classes' among the keywords, got %v", keywords)
+	}
+
+	// Test sentences are left out unless asked for
+	keywords, _ = extractKeywords(content, ExtractOptions{NumKeywords: 3, Stopwords: map[string]struct{}{}})
+	if len(keywords[0].Sentences) != 0 {
+		t.Errorf("Expected no sentences, got %v", keywords[0].Sentences)
+	}
+}
+
+// Benchmark test for segmentText function
+func BenchmarkSegmentText(b *testing.B) {
+	content := "Haskell has lazy evaluation.[26][27] Haskell pioneered type classes, e.g. Eq and Ord. " +
+		"Version 2.0 was released.\n\nThe compiler is fast! Is it? Dr. Peyton Jones thinks so."
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		segmentText(content)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// the ways the number of keywords can be chosen instead of a fixed NumKeywords
+const (
+	selectThreshold = "threshold" // every keyword scoring at least MinScore
+	selectPercent   = "percent"   // Percent of the candidate terms
+	selectLength    = "length"    // one keyword for every WordsPerKeyword words of the document
+	selectElbow     = "elbow"     // the keywords before the bend in the curve of scores
+)
+
+// SelectionOptions chooses how many keywords to keep from the text itself, so a tweet and a book do not
+// both get the same number, NumKeywords is ignored when it is used
+// Min and Max bound the number kept whatever the mode, 0 leaves that side unbounded
+type SelectionOptions struct {
+	Mode            string  `json:"mode"` // threshold, percent, length or elbow
+	MinScore        float64 `json:"min_score"`
+	Percent         float64 `json:"percent"`
+	WordsPerKeyword int     `json:"words_per_keyword"`
+	Min             int     `json:"min"`
+	Max             int     `json:"max"`
+}
+
+// checks the settings the mode needs
+func (s SelectionOptions) validate() error {
+	switch s.Mode {
+	case selectThreshold:
+		if s.MinScore <= 0 {
+			return errors.New("min_score must be above 0")
+		}
+	case selectPercent:
+		if s.Percent <= 0 || s.Percent > 100 {
+			return errors.New("percent must be above 0 and at most 100")
+		}
+	case selectLength:
+		if s.WordsPerKeyword < 1 {
+			return errors.New("words_per_keyword must be at least 1")
+		}
+	case selectElbow:
+	default:
+		return errors.New("unknown mode " + s.Mode + ", expected threshold, percent, length or elbow")
+	}
+	if s.Min < 0 || s.Max < 0 {
+		return errors.New("min and max must not be negative")
+	}
+	if s.Max != 0 && s.Max < s.Min {
+		return errors.New("max must not be below min")
+	}
+	return nil
+}
+
+// count returns how many of the ranked keywords to keep for a document of words words
+func (s SelectionOptions) count(keywords []Keyword, words int) (int, error) {
+	if err := s.validate(); err != nil {
+		return 0, err
+	}
+
+	var n int
+	switch s.Mode {
+	case selectThreshold:
+		for _, keyword := range keywords {
+			if keyword.Score >= s.MinScore {
+				n++
+			}
+		}
+	case selectPercent:
+		n = int(math.Ceil(float64(len(keywords)) * s.Percent / 100))
+	case selectLength:
+		n = (words + s.WordsPerKeyword - 1) / s.WordsPerKeyword
+	case selectElbow:
+		n = elbowCount(keywords)
+	}
+
+	n = max(n, s.Min)
+	if s.Max > 0 {
+		n = min(n, s.Max)
+	}
+	return min(n, len(keywords)), nil
+}
+
+// elbowCount finds where the curve of scores, best first, bends from falling steeply to flattening out
+// scores and ranks are scaled to run from 1 to 0 and 0 to 1, and the elbow is the keyword lying furthest
+// below the straight line between the first and last, it is the first of the flat tail so the keywords before
+// it are kept
+// every keyword is kept when there are too few to bend or the scores fall evenly
+func elbowCount(keywords []Keyword) int {
+	last := len(keywords) - 1
+	if last < 2 || keywords[0].Score == keywords[last].Score {
+		return len(keywords)
+	}
+
+	elbow, furthest := len(keywords), 0.0
+	for i, keyword := range keywords {
+		x := float64(i) / float64(last)
+		y := (keyword.Score - keywords[last].Score) / (keywords[0].Score - keywords[last].Score)
+		// the line runs from (0, 1) to (1, 0), so points below it have x + y under 1
+		if distance := 1 - x - y; distance > furthest {
+			elbow, furthest = i, distance
+		}
+	}
+	return max(elbow, 1)
+}
+
+// parseSelection reads a selection from the command line, written as mode:value such as threshold:0.01,
+// percent:5 or length:100, or elbow on its own
+func parseSelection(spec string) (SelectionOptions, error) {
+	mode, value, hasValue := strings.Cut(spec, ":")
+	selection := SelectionOptions{Mode: mode}
+	if mode == selectElbow {
+		if hasValue {
+			return SelectionOptions{}, errors.New("elbow takes no value")
+		}
+		return selection, nil
+	}
+	if !hasValue {
+		return SelectionOptions{}, errors.New(mode + " needs a value, e.g. " + mode + ":5")
+	}
+
+	var err error
+	switch mode {
+	case selectThreshold:
+		selection.MinScore, err = strconv.ParseFloat(value, 64)
+	case selectPercent:
+		selection.Percent, err = strconv.ParseFloat(value, 64)
+	case selectLength:
+		selection.WordsPerKeyword, err = strconv.Atoi(value)
+	}
+	if err != nil {
+		return SelectionOptions{}, errors.New("bad value " + value + " for " + mode)
+	}
+	return selection, selection.validate()
+}
+
+// getSelectedKeywords is getKeywords choosing how many keywords to return with selection
+// words is the length of the document in words, only used by the length mode
+func getSelectedKeywords(wordFrequency TermFrequencyIndex, selection SelectionOptions, words int) ([]string, error) {
+	ranked := rankKeywords(wordFrequency, nil)
+	n, err := selection.count(ranked, words)
+	if err != nil {
+		return nil, err
+	}
+	return keywordTerms(ranked[:n]), nil
+}
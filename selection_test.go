@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+/*
+This file tests for:
+- counting keywords by score threshold, percentage, document length and elbow
+- bounding the count with min and max
+- rejecting bad selection settings
+- parsing selections from the command line
+- selecting keywords from term frequencies and during extraction
+*/
+
+// ranked keywords with a steep drop after the first two
+var selectionKeywords = []Keyword{
+	{Term: "a", Score: 1}, {Term: "b", Score: 0.9}, {Term: "c", Score: 0.2},
+	{Term: "d", Score: 0.15}, {Term: "e", Score: 0.1}, {Term: "f", Score: 0.1},
+	{Term: "g", Score: 0.05}, {Term: "h", Score: 0.05},
+}
+
+func TestSelectionCount(t *testing.T) {
+	cases := []struct {
+		name      string
+		selection SelectionOptions
+		words     int
+		expected  int
+	}{
+		{"threshold", SelectionOptions{Mode: selectThreshold, MinScore: 0.15}, 0, 4},
+		{"percent", SelectionOptions{Mode: selectPercent, Percent: 30}, 0, 3},
+		{"length", SelectionOptions{Mode: selectLength, WordsPerKeyword: 50}, 120, 3},
+		{"length beyond candidates", SelectionOptions{Mode: selectLength, WordsPerKeyword: 1}, 500, 8},
+		{"elbow", SelectionOptions{Mode: selectElbow}, 0, 2},
+		{"min", SelectionOptions{Mode: selectThreshold, MinScore: 5, Min: 1}, 0, 1},
+		{"max", SelectionOptions{Mode: selectPercent, Percent: 100, Max: 5}, 0, 5},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			n, err := c.selection.count(selectionKeywords, c.words)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if n != c.expected {
+				t.Errorf("Expected %d, got %d", c.expected, n)
+			}
+		})
+	}
+
+	bad := []SelectionOptions{
+		{Mode: "top"},
+		{Mode: selectThreshold},
+		{Mode: selectPercent, Percent: 150},
+		{Mode: selectLength},
+		{Mode: selectElbow, Min: 3, Max: 2},
+	}
+	for _, selection := range bad {
+		if _, err := selection.count(selectionKeywords, 10); err == nil {
+			t.Errorf("Expected error for %+v, got nil", selection)
+		}
+	}
+}
+
+func TestElbowCount(t *testing.T) {
+	flat := []Keyword{{Score: 1}, {Score: 1}, {Score: 1}}
+	if n := elbowCount(flat); n != 3 {
+		t.Errorf("Expected every keyword kept for flat scores, got %d", n)
+	}
+	even := []Keyword{{Score: 3}, {Score: 2}, {Score: 1}}
+	if n := elbowCount(even); n != 3 {
+		t.Errorf("Expected every keyword kept for evenly falling scores, got %d", n)
+	}
+	if n := elbowCount(selectionKeywords[:2]); n != 2 {
+		t.Errorf("Expected both keywords kept, got %d", n)
+	}
+}
+
+func TestParseSelection(t *testing.T) {
+	cases := map[string]SelectionOptions{
+		"threshold:0.01": {Mode: selectThreshold, MinScore: 0.01},
+		"percent:5":      {Mode: selectPercent, Percent: 5},
+		"length:100":     {Mode: selectLength, WordsPerKeyword: 100},
+		"elbow":          {Mode: selectElbow},
+	}
+	for spec, expected := range cases {
+		selection, err := parseSelection(spec)
+		if err != nil || selection != expected {
+			t.Errorf("%s: expected %+v, got %+v, %v", spec, expected, selection, err)
+		}
+	}
+
+	for _, spec := range []string{"threshold", "percent:lots", "elbow:2", "top:5", "length:0"} {
+		if _, err := parseSelection(spec); err == nil {
+			t.Errorf("Expected error for %s, got nil", spec)
+		}
+	}
+}
+
+func TestGetSelectedKeywords(t *testing.T) {
+	tfi := TermFrequencyIndex{"haskell": 0.5, "monads": 0.1, "types": 0.05}
+	keywords, err := getSelectedKeywords(tfi, SelectionOptions{Mode: selectThreshold, MinScore: 0.1}, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if strings.Join(keywords, ",") != "haskell,monads" {
+		t.Errorf("Expected haskell and monads, got %v", keywords)
+	}
+}
+
+func TestExtractSelection(t *testing.T) {
+	content := strings.Repeat("Haskell monads. ", 10) + "Types."
+	opts := ExtractOptions{
+		NumKeywords: 1,
+		Stopwords:   map[string]struct{}{},
+		Selection:   &SelectionOptions{Mode: selectLength, WordsPerKeyword: 10},
+	}
+	keywords, err := extractKeywords(content, opts)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	// 21 words gives 3 keywords, ignoring NumKeywords
+	if len(keywords) != 3 {
+		t.Errorf("Expected 3 keywords, got %+v", keywords)
+	}
+
+	stopwords := writeConfigFile(t, "stopwords.txt", "the\n")
+	config := writeConfigFile(t, "config.json", `{"filters": {"stopwords": [`+strconv.Quote(stopwords)+`]}, "selection": {"max": 1}}`)
+	input := writeConfigFile(t, "input.txt", content)
+	var out bytes.Buffer
+	if err := runExtract([]string{"-config", config, "-select", "percent:100", input}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if out.String() != "haskell\n" {
+		t.Errorf("Expected the config's max to cap the selection, got %q", out.String())
+	}
+	if err := runExtract([]string{"-select", "percent", input}, &out); err == nil {
+		t.Error("Expected error for a selection without a value, got nil")
+	}
+}
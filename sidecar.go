@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+)
+
+// sidecar files sit beside the file their keywords were extracted from, notes.md gets notes.md.keywords.json
+const sidecarSuffix = ".keywords.json"
+
+// the ways keywords can be written back for the file they were extracted from
+const (
+	sidecarOutput     = "sidecar"
+	frontMatterOutput = "front-matter"
+)
+
+// updateKeywordMetadata extracts the keywords of the file at path and writes them to its sidecar or, for
+// front-matter, into its YAML front matter, reporting whether anything changed
+// front matter is left out of what is extracted so writing it does not change the keywords next time
+func updateKeywordMetadata(path, output string, extract func(content string) ([]Keyword, error)) (bool, error) {
+	if output != sidecarOutput && output != frontMatterOutput {
+		return false, errors.New("unknown output " + output + ", expected " + sidecarOutput + " or " + frontMatterOutput)
+	}
+	content, err := LoadFileContent(path)
+	if err != nil {
+		return false, err
+	}
+
+	if output == sidecarOutput {
+		keywords, err := extract(content)
+		if err != nil {
+			return false, err
+		}
+		return writeSidecar(path, keywords)
+	}
+
+	_, body, _ := splitFrontMatter(content)
+	keywords, err := extract(body)
+	if err != nil {
+		return false, err
+	}
+	return writeFrontMatter(path, content, keywordTerms(keywords))
+}
+
+// returns the sidecar path for a file
+func sidecarPath(path string) string {
+	return path + sidecarSuffix
+}
+
+// writes keywords to a file's sidecar as the same JSON array extract -format json prints
+// a sidecar already holding the same keywords is left alone, reporting whether it was written
+func writeSidecar(path string, keywords []Keyword) (bool, error) {
+	var buf bytes.Buffer
+	if err := (JSONWriter{Indent: true}).WriteKeywords(&buf, keywords); err != nil {
+		return false, err
+	}
+	if existing, err := os.ReadFile(sidecarPath(path)); err == nil && bytes.Equal(existing, buf.Bytes()) {
+		return false, nil
+	}
+	return true, writeFileAtomic(sidecarPath(path), buf.Bytes(), 0644)
+}
+
+// removes a file's sidecar, a sidecar that does not exist is not an error
+func removeSidecar(path string) error {
+	err := os.Remove(sidecarPath(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
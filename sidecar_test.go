@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/*
+This file tests for:
+- writing sidecars only when the keywords change and removing them
+- writing front matter only when the keywords change
+- leaving front matter out of what is extracted
+- the extract command writing metadata
+*/
+
+// extracts the first word of content as its only keyword, so tests can see what was extracted
+func firstWordKeyword(content string) ([]Keyword, error) {
+	fields := strings.Fields(content)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return []Keyword{{Term: strings.ToLower(fields[0]), Score: 1, Count: 1}}, nil
+}
+
+func TestUpdateKeywordMetadata(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.md")
+	os.WriteFile(path, []byte("Haskell monads\n"), 0644)
+
+	// Test sidecars
+	t.Run("Sidecar", func(t *testing.T) {
+		changed, err := updateKeywordMetadata(path, sidecarOutput, firstWordKeyword)
+		if err != nil || !changed {
+			t.Fatalf("Expected a new sidecar, got %v, %v", changed, err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "notes.md.keywords.json"))
+		if err != nil || !bytes.Contains(data, []byte(`"term": "haskell"`)) {
+			t.Errorf("Expected a sidecar with haskell, got %q, %v", data, err)
+		}
+		// the same keywords again leave the sidecar as it is
+		if changed, err := updateKeywordMetadata(path, sidecarOutput, firstWordKeyword); err != nil || changed {
+			t.Errorf("Expected the sidecar to be unchanged, got %v, %v", changed, err)
+		}
+		if err := removeSidecar(path); err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+		if err := removeSidecar(path); err != nil {
+			t.Errorf("Expected no error removing a missing sidecar, got: %v", err)
+		}
+	})
+
+	// Test front matter
+	t.Run("FrontMatter", func(t *testing.T) {
+		changed, err := updateKeywordMetadata(path, frontMatterOutput, firstWordKeyword)
+		if err != nil || !changed {
+			t.Fatalf("Expected the file to change, got %v, %v", changed, err)
+		}
+		data, _ := os.ReadFile(path)
+		if string(data) != "---\nkeywords:\n  - haskell\n---\nHaskell monads\n" {
+			t.Errorf("Unexpected file:\n%s", data)
+		}
+
+		// the front matter is not extracted, so the first word is still haskell
+		changed, err = updateKeywordMetadata(path, frontMatterOutput, firstWordKeyword)
+		if err != nil || changed {
+			t.Errorf("Expected nothing to change, got %v, %v", changed, err)
+		}
+	})
+
+	if _, err := updateKeywordMetadata(path, "xml", firstWordKeyword); err == nil {
+		t.Error("Expected error for an unknown output, got nil")
+	}
+}
+
+func TestRunExtractWrite(t *testing.T) {
+	path := writeConfigFile(t, "notes.md", "---\ntitle: Notes\n---\nHaskell monads. Haskell laziness.\n")
+	stopwords := writeConfigFile(t, "stopwords.txt", "the\n")
+
+	var out bytes.Buffer
+	args := []string{"-write", frontMatterOutput, "-n", "1", "-min-length", "3", path}
+	if err := runExtract(append([]string{"-config", writeConfigFile(t, "config.json", `{"filters": {"stopwords": ["`+filepath.ToSlash(stopwords)+`"]}}`)}, args...), &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if out.String() != "updated "+path+"\n" {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "---\ntitle: Notes\nkeywords:\n  - haskell\n---\n") {
+		t.Errorf("Unexpected file:\n%s", data)
+	}
+
+	// writing the same sidecar twice reports it unchanged the second time
+	for _, expected := range []string{"updated ", "unchanged "} {
+		out.Reset()
+		if err := runExtract([]string{"-write", sidecarOutput, "-n", "1", path}, &out); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if !strings.HasPrefix(out.String(), expected) {
+			t.Errorf("Expected output starting %q, got %q", expected, out.String())
+		}
+	}
+
+	if err := runExtract([]string{"-write", sidecarOutput}, &out); err == nil {
+		t.Error("Expected error writing without a file, got nil")
+	}
+}
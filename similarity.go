@@ -0,0 +1,351 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// SimilarityIndex stores the TF-IDF keyword vector of every document in a corpus for cosine similarity search
+// vectors keep at most MaxTerms terms, 0 keeps every term, and queries must be counted with the same options
+// the index was built with
+// Counts and Fingerprints keep each document's term counts and a hash of its text, so documents can be
+// added, changed or removed later without counting the whole corpus again
+type SimilarityIndex struct {
+	Documents         int                       `json:"documents"`
+	MaxTerms          int                       `json:"max_terms"`
+	DocumentFrequency DocumentFrequencyIndex    `json:"document_frequency"`
+	Vectors           map[string]KeywordVector  `json:"vectors"`
+	Counts            map[string]TermCountIndex `json:"counts"`
+	Fingerprints      map[string]string         `json:"fingerprints"`
+}
+
+// SimilarityUpdate counts what an update did to an index
+// Unchanged documents had the same text as the indexed copy and Skipped documents had no words to count
+type SimilarityUpdate struct {
+	Added     int
+	Updated   int
+	Unchanged int
+	Removed   int
+	Skipped   int
+}
+
+// returns an empty index keeping at most maxTerms terms per vector
+func newSimilarityIndex(maxTerms int) (*SimilarityIndex, error) {
+	if maxTerms < 0 {
+		return nil, errors.New("maximum terms per vector must not be negative")
+	}
+	return &SimilarityIndex{
+		MaxTerms:          maxTerms,
+		DocumentFrequency: make(DocumentFrequencyIndex),
+		Vectors:           make(map[string]KeywordVector),
+		Counts:            make(map[string]TermCountIndex),
+		Fingerprints:      make(map[string]string),
+	}, nil
+}
+
+// buildSimilarityIndex counts every document with opts and stores its keyword vector
+// documents with no words to count are left out, and document IDs must be unique
+func buildSimilarityIndex(docs []Document, opts ExtractOptions, maxTerms int) (*SimilarityIndex, error) {
+	index, err := newSimilarityIndex(maxTerms)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := index.Update(docs, nil, opts); err != nil {
+		return nil, err
+	}
+	if index.Documents == 0 {
+		return nil, errors.New("no documents with words to index")
+	}
+	return index, nil
+}
+
+// returns a hex SHA-256 of a document's text, used to tell whether a document changed since it was indexed
+func documentFingerprint(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// Update removes the documents with the ids in remove then adds docs, replacing indexed documents with the
+// same id, and reweights every vector by the new document frequencies
+// a document whose text has the same fingerprint as its indexed copy is left alone, so adding the same
+// documents twice never counts them twice, and only new or changed documents are counted with opts
+func (index *SimilarityIndex) Update(docs []Document, remove []string, opts ExtractOptions) (SimilarityUpdate, error) {
+	var update SimilarityUpdate
+	if index.Counts == nil || index.Fingerprints == nil {
+		return update, errors.New("index has no document counts to update, build it again")
+	}
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return update, err
+	}
+
+	seen := make(map[string]struct{}, len(docs))
+	for _, doc := range docs {
+		if _, ok := seen[doc.ID]; ok {
+			return update, errors.New("duplicate document id " + doc.ID)
+		}
+		seen[doc.ID] = struct{}{}
+	}
+
+	for _, id := range remove {
+		if index.removeCounts(id) {
+			update.Removed++
+		}
+	}
+
+	for _, doc := range docs {
+		fingerprint := documentFingerprint(doc.Text)
+		if index.Fingerprints[doc.ID] == fingerprint {
+			update.Unchanged++
+			continue
+		}
+		// the old copy goes even when the new one has nothing to count
+		replaced := index.removeCounts(doc.ID)
+
+		tci, err := countTerms(doc.Text, opts)
+		if err != nil {
+			update.Skipped++
+			if replaced {
+				update.Removed++
+			}
+			continue
+		}
+		index.Counts[doc.ID] = tci
+		index.Fingerprints[doc.ID] = fingerprint
+		for term := range tci {
+			index.DocumentFrequency[term]++
+		}
+		if replaced {
+			update.Updated++
+		} else {
+			update.Added++
+		}
+	}
+
+	index.Documents = len(index.Counts)
+	if update.Added+update.Updated+update.Removed > 0 {
+		// any change to the document frequencies changes every document's weights
+		index.Vectors = make(map[string]KeywordVector, len(index.Counts))
+		for id, tci := range index.Counts {
+			index.Vectors[id] = index.vector(tci)
+		}
+	}
+	return update, nil
+}
+
+// takes a document's counts out of the document frequencies, reporting whether it was indexed
+// the caller reweights the vectors afterwards
+func (index *SimilarityIndex) removeCounts(id string) bool {
+	tci, ok := index.Counts[id]
+	if !ok {
+		return false
+	}
+	for term := range tci {
+		index.DocumentFrequency[term]--
+		if index.DocumentFrequency[term] <= 0 {
+			delete(index.DocumentFrequency, term)
+		}
+	}
+	delete(index.Counts, id)
+	delete(index.Fingerprints, id)
+	delete(index.Vectors, id)
+	return true
+}
+
+// weights counts by the index's document frequencies and turns them into a keyword vector
+func (index *SimilarityIndex) vector(tci TermCountIndex) KeywordVector {
+	return newKeywordVector(getTFIDF(tci, index.DocumentFrequency, index.Documents), index.MaxTerms)
+}
+
+// QueryText returns the k documents most similar to content, counted with opts
+func (index *SimilarityIndex) QueryText(content string, opts ExtractOptions, k int) ([]DocumentScore, error) {
+	tci, err := countTerms(content, opts)
+	if err != nil {
+		return nil, err
+	}
+	return index.Query(index.vector(tci), k, ""), nil
+}
+
+// SimilarTo returns the k documents most similar to an indexed document, leaving the document itself out
+func (index *SimilarityIndex) SimilarTo(id string, k int) ([]DocumentScore, error) {
+	vector, ok := index.Vectors[id]
+	if !ok {
+		return nil, errors.New("document " + id + " is not in the index")
+	}
+	return index.Query(vector, k, id), nil
+}
+
+// Query returns the k documents with the highest cosine similarity to vector, best first
+// documents sharing no terms with the vector are left out, as is the document with the id exclude
+func (index *SimilarityIndex) Query(vector KeywordVector, k int, exclude string) []DocumentScore {
+	var results []DocumentScore
+	for id, candidate := range index.Vectors {
+		if id == exclude {
+			continue
+		}
+		if score := cosineSimilarity(vector, candidate); score > 0 {
+			results = append(results, DocumentScore{ID: id, Score: score})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	if k > 0 && len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+// saveSimilarityIndex writes the index to path as JSON
+func saveSimilarityIndex(path string, index *SimilarityIndex) error {
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+// loadSimilarityIndex reads an index written by saveSimilarityIndex
+func loadSimilarityIndex(path string) (*SimilarityIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.New("Error opening file, check file path: " + err.Error())
+	}
+	var index SimilarityIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, errors.New(path + ": " + err.Error())
+	}
+	if index.Vectors == nil {
+		return nil, errors.New(path + ": not a similarity index")
+	}
+	return &index, nil
+}
+
+// runSimilar is the similar command
+// with -dataset it builds an index and saves it to -index, with -add or -remove it updates the saved index,
+// creating it if needed, otherwise it loads -index and finds the documents most similar to the indexed
+// document -id or to the text of a file, or stdin
+func runSimilar(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("similar", flag.ContinueOnError)
+	indexPath := flags.String("index", "", "similarity index file to save to or search")
+	dataset := flags.String("dataset", "", "build the index from this JSONL file or directory of .txt documents")
+	add := flags.String("add", "", "add this document, JSONL file or directory to the index, replacing changed documents with the same id")
+	remove := flags.String("remove", "", "comma separated ids of documents to remove from the index")
+	maxTerms := flags.Int("terms", 50, "number of keywords kept in each document's vector when building, 0 for all")
+	id := flags.String("id", "", "find documents similar to this indexed document")
+	k := flags.Int("k", 10, "number of similar documents to print")
+	configPath := flags.String("config", "", "JSON, YAML or TOML config file, use the same one to build and search")
+	format := flags.String("format", "table", "output format: "+strings.Join(outputFormatNames(), ", "))
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *indexPath == "" {
+		return errors.New("similar needs an -index")
+	}
+	if *dataset != "" && (*add != "" || *remove != "") {
+		return errors.New("use either -dataset to rebuild or -add and -remove to update, not both")
+	}
+
+	config, err := loadConfigWithFlags(*configPath, flags, map[string]func(*Config){
+		"format": func(c *Config) { c.Format = *format },
+	})
+	if err != nil {
+		return err
+	}
+	if config.Format == "" {
+		config.Format = *format
+	}
+	opts, err := config.options()
+	if err != nil {
+		return err
+	}
+
+	if *dataset != "" {
+		docs, err := loadDocuments(*dataset)
+		if err != nil {
+			return err
+		}
+		index, err := buildSimilarityIndex(docs, opts, *maxTerms)
+		if err != nil {
+			return err
+		}
+		if err := saveSimilarityIndex(*indexPath, index); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "indexed %d documents\n", index.Documents)
+		return nil
+	}
+
+	if *add != "" || *remove != "" {
+		// updating a missing index starts a new one
+		index, err := newSimilarityIndex(*maxTerms)
+		if err != nil {
+			return err
+		}
+		if fileExists(*indexPath) {
+			index, err = loadSimilarityIndex(*indexPath)
+			if err != nil {
+				return err
+			}
+		}
+		var docs []Document
+		if *add != "" {
+			docs, err = loadDocumentsOrText(*add)
+			if err != nil {
+				return err
+			}
+		}
+		update, err := index.Update(docs, parseNameList(*remove), opts)
+		if err != nil {
+			return err
+		}
+		if err := saveSimilarityIndex(*indexPath, index); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "added %d, updated %d, unchanged %d, removed %d, %d documents indexed\n",
+			update.Added, update.Updated, update.Unchanged, update.Removed, index.Documents)
+		return nil
+	}
+
+	index, err := loadSimilarityIndex(*indexPath)
+	if err != nil {
+		return err
+	}
+
+	var results []DocumentScore
+	switch {
+	case *id != "":
+		results, err = index.SimilarTo(*id, *k)
+	case flags.NArg() > 1:
+		return errors.New("similar takes at most one file")
+	default:
+		var content string
+		if flags.NArg() == 1 {
+			content, err = LoadFileContent(flags.Arg(0))
+		} else {
+			var data []byte
+			data, err = io.ReadAll(os.Stdin)
+			content = string(data)
+		}
+		if err != nil {
+			return err
+		}
+		results, err = index.QueryText(content, opts, *k)
+	}
+	if err != nil {
+		return err
+	}
+	return writeDocumentScores(stdout, config.Format, results)
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+/*
+This file tests for:
+- building an index and finding documents similar to an indexed one
+- searching with new text
+- rejecting duplicate ids and unknown documents
+- saving and loading the index
+- adding, changing and removing documents matching a full rebuild
+- skipping documents whose text has not changed
+- the similar command building then searching
+*/
+
+// a small corpus of two topics
+var similarityDocs = []Document{
+	{ID: "haskell1", Text: "Haskell monads and lazy evaluation in functional programming."},
+	{ID: "haskell2", Text: "Functional programming with Haskell type classes and monads."},
+	{ID: "rust1", Text: "Rust ownership and the borrow checker prevent data races."},
+	{ID: "rust2", Text: "The Rust borrow checker enforces ownership rules."},
+	{ID: "empty", Text: "a an"},
+}
+
+func TestSimilarityIndex(t *testing.T) {
+	opts := ExtractOptions{Stopwords: map[string]struct{}{"the": {}, "and": {}, "with": {}}}
+	index, err := buildSimilarityIndex(similarityDocs, opts, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if index.Documents != 4 {
+		t.Errorf("Expected 4 indexed documents, got %d", index.Documents)
+	}
+
+	// Test finding documents like an indexed one
+	t.Run("SimilarTo", func(t *testing.T) {
+		results, err := index.SimilarTo("haskell1", 2)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(results) != 1 || results[0].ID != "haskell2" {
+			t.Errorf("Expected only haskell2, got %+v", results)
+		}
+		if _, err := index.SimilarTo("missing", 2); err == nil {
+			t.Error("Expected error for unknown document, got nil")
+		}
+	})
+
+	// Test searching with new text
+	t.Run("QueryText", func(t *testing.T) {
+		results, err := index.QueryText("borrow checker ownership", opts, 5)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(results) != 2 || !strings.HasPrefix(results[0].ID, "rust") || !strings.HasPrefix(results[1].ID, "rust") {
+			t.Errorf("Expected the two rust documents, got %+v", results)
+		}
+		if results[0].Score > 1.0001 || results[0].Score < results[1].Score {
+			t.Errorf("Expected scores in descending order no higher than 1, got %+v", results)
+		}
+	})
+
+	// Test saving and loading
+	t.Run("Persistence", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "index.json")
+		if err := saveSimilarityIndex(path, index); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		loaded, err := loadSimilarityIndex(path)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		before, _ := index.SimilarTo("rust1", 1)
+		after, _ := loaded.SimilarTo("rust1", 1)
+		if len(after) != 1 || after[0] != before[0] {
+			t.Errorf("Expected the same results after loading, got %+v and %+v", before, after)
+		}
+
+		os.WriteFile(path, []byte(`{"documents": 1}`), 0644)
+		if _, err := loadSimilarityIndex(path); err == nil {
+			t.Error("Expected error for a file that is not an index, got nil")
+		}
+	})
+
+	// Test duplicate ids are rejected
+	t.Run("DuplicateIDs", func(t *testing.T) {
+		docs := []Document{{ID: "a", Text: "haskell"}, {ID: "a", Text: "rust"}}
+		if _, err := buildSimilarityIndex(docs, opts, 0); err == nil {
+			t.Error("Expected error for duplicate ids, got nil")
+		}
+	})
+}
+
+func TestSimilarityIndexUpdate(t *testing.T) {
+	opts := ExtractOptions{Stopwords: map[string]struct{}{"the": {}, "and": {}, "with": {}}}
+	index, err := buildSimilarityIndex(similarityDocs[:2], opts, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	changed := Document{ID: "haskell2", Text: "Haskell type classes."}
+	update, err := index.Update([]Document{similarityDocs[0], changed, similarityDocs[2], similarityDocs[3], similarityDocs[4]}, []string{"haskell1", "missing"}, opts)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	// haskell1 is removed then added back, as its text was not indexed anymore
+	expected := SimilarityUpdate{Added: 3, Updated: 1, Removed: 1, Skipped: 1}
+	if update != expected {
+		t.Errorf("Expected %+v, got %+v", expected, update)
+	}
+
+	rebuilt, _ := buildSimilarityIndex([]Document{similarityDocs[0], changed, similarityDocs[2], similarityDocs[3]}, opts, 0)
+	if !reflect.DeepEqual(index.DocumentFrequency, rebuilt.DocumentFrequency) || index.Documents != rebuilt.Documents {
+		t.Errorf("Expected the same document frequencies as a rebuild, got %v and %v", index.DocumentFrequency, rebuilt.DocumentFrequency)
+	}
+	for id, vector := range rebuilt.Vectors {
+		for term, weight := range vector {
+			if math.Abs(index.Vectors[id][term]-weight) > 1e-9 {
+				t.Errorf("Expected %s weight of %s to match a rebuild, got %f and %f", id, term, index.Vectors[id][term], weight)
+			}
+		}
+	}
+
+	// Test adding the same documents again counts nothing twice
+	t.Run("Unchanged", func(t *testing.T) {
+		before := index.DocumentFrequency["rust"]
+		update, err := index.Update(similarityDocs[2:4], nil, opts)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if update.Unchanged != 2 || update.Added != 0 || index.DocumentFrequency["rust"] != before {
+			t.Errorf("Expected 2 unchanged documents and rust in %d documents, got %+v and %d", before, update, index.DocumentFrequency["rust"])
+		}
+	})
+
+	// Test removing every document with a term drops the term
+	t.Run("Remove", func(t *testing.T) {
+		if _, err := index.Update(nil, []string{"rust1", "rust2"}, opts); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if _, ok := index.DocumentFrequency["rust"]; ok || index.Documents != 2 {
+			t.Errorf("Expected rust to be dropped leaving 2 documents, got %d documents", index.Documents)
+		}
+	})
+}
+
+func TestRunSimilar(t *testing.T) {
+	dir := t.TempDir()
+	for _, doc := range similarityDocs {
+		os.WriteFile(filepath.Join(dir, doc.ID+".txt"), []byte(doc.Text), 0644)
+	}
+	indexPath := filepath.Join(t.TempDir(), "index.json")
+
+	var out bytes.Buffer
+	if err := runSimilar([]string{"-index", indexPath, "-dataset", dir}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if out.String() != "indexed 4 documents\n" {
+		t.Errorf("Unexpected build output: %q", out.String())
+	}
+
+	out.Reset()
+	if err := runSimilar([]string{"-index", indexPath, "-id", "rust2", "-k", "1", "-format", "plain"}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if out.String() != "rust1\n" {
+		t.Errorf("Expected rust1, got %q", out.String())
+	}
+
+	query := filepath.Join(t.TempDir(), "query.txt")
+	os.WriteFile(query, []byte("Haskell monads"), 0644)
+	out.Reset()
+	if err := runSimilar([]string{"-index", indexPath, "-format", "csv", query}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "rank,id,score\n1,haskell") {
+		t.Errorf("Expected a haskell document first, got:\n%s", out.String())
+	}
+
+	// Test updating the saved index
+	out.Reset()
+	if err := runSimilar([]string{"-index", indexPath, "-add", dir, "-remove", "rust1"}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if out.String() != "added 1, updated 0, unchanged 3, removed 1, 4 documents indexed\n" {
+		t.Errorf("Unexpected update output: %q", out.String())
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"unicode/utf8"
+)
+
+// Span is a range of text given both as byte offsets and as rune offsets
+type Span struct {
+	Start     int `json:"start"`
+	End       int `json:"end"`
+	RuneStart int `json:"rune_start"`
+	RuneEnd   int `json:"rune_end"`
+}
+
+// Snippet is a window of text around a keyword, Span locates it in the original content
+// Highlights locate each occurrence of the keyword relative to the start of Text
+type Snippet struct {
+	Span
+	Text       string `json:"text"`
+	Highlights []Span `json:"highlights"`
+}
+
+// SnippetOptions controls keyword-in-context snippets
+// Window is the number of words kept either side of the keyword and Max is the number of snippets per keyword
+type SnippetOptions struct {
+	Window int
+	Max    int
+}
+
+// builds up to opts.Max snippets for each keyword from the tokens of content
+// multi-word keywords such as collocations and vocabulary labels match their words against consecutive tokens, ignoring case
+// snippets showing more of the chosen keywords rank first, otherwise earlier snippets rank first
+func getKeywordSnippets(content string, tokens []Token, keywords []string, wordSplitter *regexp.Regexp, opts SnippetOptions) map[string][]Snippet {
+	snippets := make(map[string][]Snippet, len(keywords))
+
+	words := make(map[string][]string, len(keywords))
+	for _, kw := range keywords {
+		words[kw] = keywordWords(kw, wordSplitter)
+	}
+
+	// rune offset of every token start and end so snippets can report both kinds of offset
+	runeOffset := runeOffsets(content, tokens)
+
+	type candidate struct {
+		snippet Snippet
+		score   int
+	}
+
+	for _, kw := range keywords {
+		n := len(words[kw])
+		if n == 0 {
+			continue
+		}
+		var candidates []candidate
+
+		for i, token := range tokens {
+			if !matchesAt(tokens, i, words[kw]) {
+				continue
+			}
+			// skip occurrences already shown in the previous snippet
+			if len(candidates) > 0 && token.Start < candidates[len(candidates)-1].snippet.End {
+				continue
+			}
+
+			first := max(i-opts.Window, 0)
+			last := min(i+n-1+opts.Window, len(tokens)-1)
+			snippet := Snippet{
+				Span: Span{
+					Start:     tokens[first].Start,
+					End:       tokens[last].End,
+					RuneStart: runeOffset[tokens[first].Start],
+					RuneEnd:   runeOffset[tokens[last].End],
+				},
+			}
+			snippet.Text = content[snippet.Start:snippet.End]
+
+			// highlight every occurrence of the keyword and score the other keywords shown
+			window := tokens[first : last+1]
+			shown := 0
+			for _, other := range keywords {
+				found := false
+				for j := range window {
+					if !matchesAt(window, j, words[other]) {
+						continue
+					}
+					found = true
+					if other == kw {
+						end := window[j+n-1].End
+						snippet.Highlights = append(snippet.Highlights, Span{
+							Start:     window[j].Start - snippet.Start,
+							End:       end - snippet.Start,
+							RuneStart: runeOffset[window[j].Start] - snippet.RuneStart,
+							RuneEnd:   runeOffset[end] - snippet.RuneStart,
+						})
+					}
+				}
+				if found {
+					shown++
+				}
+			}
+
+			candidates = append(candidates, candidate{snippet: snippet, score: shown})
+		}
+
+		// keep the top snippets, sort.SliceStable keeps earlier snippets first on ties
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].score > candidates[j].score
+		})
+		for i := 0; i < opts.Max && i < len(candidates); i++ {
+			snippets[kw] = append(snippets[kw], candidates[i].snippet)
+		}
+	}
+
+	return snippets
+}
+
+// returns the lowercase words of a keyword, split the same way as the content
+func keywordWords(keyword string, wordSplitter *regexp.Regexp) []string {
+	var words []string
+	for _, token := range tokenize(keyword, wordSplitter) {
+		words = append(words, token.Text)
+	}
+	return words
+}
+
+// reports whether words appear as consecutive tokens starting at tokens[i]
+func matchesAt(tokens []Token, i int, words []string) bool {
+	if len(words) == 0 || i+len(words) > len(tokens) {
+		return false
+	}
+	for j, word := range words {
+		if tokens[i+j].Text != word {
+			return false
+		}
+	}
+	return true
+}
+
+// maps the byte offset of every token boundary to its rune offset
+func runeOffsets(content string, tokens []Token) map[int]int {
+	offsets := make(map[int]int, len(tokens)*2)
+
+	bytePos, runePos := 0, 0
+	for _, token := range tokens {
+		for _, boundary := range []int{token.Start, token.End} {
+			runePos += utf8.RuneCountInString(content[bytePos:boundary])
+			bytePos = boundary
+			offsets[boundary] = runePos
+		}
+	}
+
+	return offsets
+}
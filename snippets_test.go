@@ -0,0 +1,199 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+/*
+This file tests for:
+- tokenizing keeps byte offsets and drops empty words
+- snippet windows and byte offsets
+- rune offsets with multibyte text
+- highlights of every occurrence inside a snippet
+- limiting and ranking snippets
+- snippets from the extraction pipeline
+- snippets locate text in the original content when filters remove citations and links
+- multi-word keywords from collocations and vocabulary labels
+- negative snippet windows and limits return errors
+*/
+func TestTokenize(t *testing.T) {
+	content := "  Hello, World! Again"
+	tokens := tokenize(content, regexp.MustCompile(`\W+`))
+
+	expected := []string{"hello", "world", "again"}
+	if len(tokens) != len(expected) {
+		t.Fatalf("Expected %d tokens, got %d: %v", len(expected), len(tokens), tokens)
+	}
+	for i, token := range tokens {
+		if token.Text != expected[i] {
+			t.Errorf("Expected token '%s', got '%s'", expected[i], token.Text)
+		}
+		// offsets point at the original, not lowercased, text
+		if got := content[token.Start:token.End]; got != []string{"Hello", "World", "Again"}[i] {
+			t.Errorf("Expected offsets to cover original text, got %q", got)
+		}
+	}
+}
+
+func TestGetKeywordSnippets(t *testing.T) {
+	wordSplitter := regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+	// Test the window of words around a keyword
+	t.Run("Window", func(t *testing.T) {
+		content := "one two three haskell four five six"
+		tokens := tokenize(content, wordSplitter)
+
+		snippets := getKeywordSnippets(content, tokens, []string{"haskell"}, wordSplitter, SnippetOptions{Window: 1, Max: 5})
+
+		if len(snippets["haskell"]) != 1 {
+			t.Fatalf("Expected 1 snippet, got %d", len(snippets["haskell"]))
+		}
+		snippet := snippets["haskell"][0]
+		if snippet.Text != "three haskell four" {
+			t.Errorf("Expected 'three haskell four', got %q", snippet.Text)
+		}
+		if content[snippet.Start:snippet.End] != snippet.Text {
+			t.Errorf("Expected byte offsets to match the snippet text")
+		}
+		if len(snippet.Highlights) != 1 || snippet.Text[snippet.Highlights[0].Start:snippet.Highlights[0].End] != "haskell" {
+			t.Errorf("Expected a highlight over 'haskell', got %v", snippet.Highlights)
+		}
+	})
+
+	// Test rune offsets differ from byte offsets after multibyte text
+	t.Run("RuneOffsets", func(t *testing.T) {
+		content := "café naïve haskell"
+		tokens := tokenize(content, wordSplitter)
+
+		snippet := getKeywordSnippets(content, tokens, []string{"haskell"}, wordSplitter, SnippetOptions{Window: 0, Max: 1})["haskell"][0]
+
+		if snippet.Start != 13 || snippet.RuneStart != 11 {
+			t.Errorf("Expected byte start 13 and rune start 11, got %d and %d", snippet.Start, snippet.RuneStart)
+		}
+		if snippet.RuneEnd-snippet.RuneStart != 7 {
+			t.Errorf("Expected rune length 7, got %d", snippet.RuneEnd-snippet.RuneStart)
+		}
+	})
+
+	// Test nearby occurrences share a snippet and are all highlighted
+	t.Run("MultipleHighlights", func(t *testing.T) {
+		content := "lazy code is lazy"
+		tokens := tokenize(content, wordSplitter)
+
+		snippets := getKeywordSnippets(content, tokens, []string{"lazy"}, wordSplitter, SnippetOptions{Window: 3, Max: 5})["lazy"]
+
+		if len(snippets) != 1 {
+			t.Fatalf("Expected 1 snippet, got %d", len(snippets))
+		}
+		if len(snippets[0].Highlights) != 2 {
+			t.Errorf("Expected 2 highlights, got %d", len(snippets[0].Highlights))
+		}
+	})
+
+	// Test snippets showing other keywords rank first and Max is respected
+	t.Run("RankingAndLimit", func(t *testing.T) {
+		content := "haskell alone here and there then haskell with compiler"
+		tokens := tokenize(content, wordSplitter)
+
+		snippets := getKeywordSnippets(content, tokens, []string{"haskell", "compiler"}, wordSplitter, SnippetOptions{Window: 2, Max: 1})
+
+		if len(snippets["haskell"]) != 1 {
+			t.Fatalf("Expected 1 snippet, got %d", len(snippets["haskell"]))
+		}
+		if snippets["haskell"][0].Text != "there then haskell with compiler" {
+			t.Errorf("Expected snippet with 'compiler' first, got %q", snippets["haskell"][0].Text)
+		}
+	})
+
+	// Test multi-word keywords match consecutive words whatever their case
+	t.Run("MultiWord", func(t *testing.T) {
+		content := "the Glasgow Haskell Compiler builds haskell"
+		tokens := tokenize(content, wordSplitter)
+
+		snippets := getKeywordSnippets(content, tokens, []string{"glasgow haskell compiler"}, wordSplitter, SnippetOptions{Window: 1, Max: 5})["glasgow haskell compiler"]
+
+		if len(snippets) != 1 {
+			t.Fatalf("Expected 1 snippet, got %d", len(snippets))
+		}
+		if snippets[0].Text != "the Glasgow Haskell Compiler builds" {
+			t.Errorf("Expected 'the Glasgow Haskell Compiler builds', got %q", snippets[0].Text)
+		}
+		highlight := snippets[0].Highlights[0]
+		if snippets[0].Text[highlight.Start:highlight.End] != "Glasgow Haskell Compiler" {
+			t.Errorf("Expected a highlight over the whole phrase, got %v", highlight)
+		}
+	})
+}
+
+func TestExtractKeywordsWithSnippets(t *testing.T) {
+	content := "Haskell is a lazy language. Programmers like Haskell."
+	keywords, err := extractKeywords(content, ExtractOptions{
+		NumKeywords: 1,
+		Stopwords:   map[string]struct{}{},
+		Snippets:    &SnippetOptions{Window: 2, Max: 2},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if keywords[0].Term != "haskell" {
+		t.Fatalf("Expected 'haskell', got %q", keywords[0].Term)
+	}
+	if len(keywords[0].Snippets) != 2 {
+		t.Errorf("Expected 2 snippets, got %d", len(keywords[0].Snippets))
+	}
+
+	// Test collocations and the mixed-case labels they map to get snippets
+	vocabulary, err := newVocabulary([]Concept{{ID: "http://example.org/lazy", PrefLabel: "Lazy Evaluation"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	content = "Lazy evaluation delays work. Haskell uses lazy evaluation. Strict code avoids lazy evaluation."
+	keywords, err = extractKeywords(content, ExtractOptions{
+		NumKeywords:  1,
+		Stopwords:    map[string]struct{}{},
+		Collocations: &CollocationOptions{MaxN: 2, MinCount: 2, Measure: "tscore"},
+		Vocabulary:   &VocabularyOptions{Vocabulary: vocabulary},
+		Snippets:     &SnippetOptions{Window: 1, Max: 5},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if keywords[0].Term != "Lazy Evaluation" {
+		t.Fatalf("Expected 'Lazy Evaluation', got %q", keywords[0].Term)
+	}
+	if len(keywords[0].Snippets) != 3 {
+		t.Errorf("Expected 3 snippets, got %d", len(keywords[0].Snippets))
+	}
+
+	// Test offsets and text come from the original content, not the filtered text
+	content = "Haskell[26][27] is lazy. See https://example.com/haskell for haskell docs."
+	keywords, err = extractKeywords(content, ExtractOptions{
+		NumKeywords: 1,
+		Stopwords:   map[string]struct{}{},
+		Snippets:    &SnippetOptions{Window: 1, Max: 2},
+		Sentences:   true,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if keywords[0].Term != "haskell" || len(keywords[0].Snippets) != 2 {
+		t.Fatalf("Expected 2 snippets for 'haskell', got %v", keywords)
+	}
+	for i, expected := range []string{"Haskell[26][27] is", "for haskell docs"} {
+		snippet := keywords[0].Snippets[i]
+		if snippet.Text != expected || content[snippet.Start:snippet.End] != expected {
+			t.Errorf("Expected snippet %q at its offsets, got %q at %q", expected, snippet.Text, content[snippet.Start:snippet.End])
+		}
+	}
+	if sentences := keywords[0].Sentences; len(sentences) != 2 || content[sentences[0].Start:sentences[0].End] != "Haskell[26][27] is lazy." {
+		t.Errorf("Expected the sentences from the original content, got %v", sentences)
+	}
+
+	for _, opts := range []SnippetOptions{{Window: -1, Max: 1}, {Window: 1, Max: -1}} {
+		if _, err := extractKeywords(content, ExtractOptions{Snippets: &opts}); err == nil {
+			t.Errorf("Expected error for snippet options %+v, got nil", opts)
+		}
+	}
+}
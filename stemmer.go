@@ -0,0 +1,234 @@
+package main
+
+import (
+	"strings"
+)
+
+// stem reduces an English word to its Porter stem so that "functions" and "function" match
+// words of two letters or fewer are returned lowercased but otherwise unchanged
+func stem(word string) string {
+	word = strings.ToLower(word)
+	if len(word) <= 2 {
+		return word
+	}
+
+	word = porterStep1a(word)
+	word = porterStep1b(word)
+	word = porterStep1c(word)
+	word = porterStep2(word)
+	word = porterStep3(word)
+	word = porterStep4(word)
+	word = porterStep5(word)
+	return word
+}
+
+// splits a phrase into lowercase words on spaces and hyphens
+func phraseWords(phrase string) []string {
+	return strings.FieldsFunc(strings.ToLower(phrase), func(r rune) bool {
+		return r == ' ' || r == '-' || r == '\t'
+	})
+}
+
+// stemPhrase stems every word of a phrase split on spaces and hyphens
+func stemPhrase(phrase string) string {
+	words := phraseWords(phrase)
+	for i, word := range words {
+		words[i] = stem(word)
+	}
+	return strings.Join(words, " ")
+}
+
+// inflectionStem strips only the plural, -ed and -ing endings from every word of a phrase
+// so "compilers" matches "compiler" but "functional" stays apart from "function"
+func inflectionStem(phrase string) string {
+	words := phraseWords(phrase)
+	for i, word := range words {
+		if len(word) > 2 {
+			words[i] = porterStep1c(porterStep1b(porterStep1a(word)))
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// checks if the letter at index i is a consonant, y is a consonant unless it follows one
+func isConsonant(word string, i int) bool {
+	switch word[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !isConsonant(word, i-1)
+	}
+	return true
+}
+
+// measure counts the vowel-consonant sequences in a stem, m in the Porter paper
+func measure(stem string) int {
+	m := 0
+	prevVowel := false
+	for i := range stem {
+		vowel := !isConsonant(stem, i)
+		if prevVowel && !vowel {
+			m++
+		}
+		prevVowel = vowel
+	}
+	return m
+}
+
+// checks if the stem contains a vowel
+func containsVowel(stem string) bool {
+	for i := range stem {
+		if !isConsonant(stem, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// checks if the stem ends in a double consonant such as -tt or -ss
+func endsDoubleConsonant(stem string) bool {
+	n := len(stem)
+	return n >= 2 && stem[n-1] == stem[n-2] && isConsonant(stem, n-1)
+}
+
+// checks if the stem ends consonant-vowel-consonant where the last consonant is not w, x or y
+func endsCVC(stem string) bool {
+	n := len(stem)
+	if n < 3 || !isConsonant(stem, n-3) || isConsonant(stem, n-2) || !isConsonant(stem, n-1) {
+		return false
+	}
+	last := stem[n-1]
+	return last != 'w' && last != 'x' && last != 'y'
+}
+
+// replaces the first matching suffix when the remaining stem has a measure above minMeasure
+// returns the word unchanged if no suffix matches, the rules are tried in order
+func replaceSuffix(word string, rules [][2]string, minMeasure int) string {
+	for _, rule := range rules {
+		if strings.HasSuffix(word, rule[0]) {
+			stem := word[:len(word)-len(rule[0])]
+			if measure(stem) > minMeasure {
+				return stem + rule[1]
+			}
+			return word
+		}
+	}
+	return word
+}
+
+// plurals: sses -> ss, ies -> i, ss -> ss, s -> nothing
+func porterStep1a(word string) string {
+	switch {
+	case strings.HasSuffix(word, "sses"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ies"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ss"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// past tenses and gerunds: eed, ed and ing, tidying up the stem that is left behind
+func porterStep1b(word string) string {
+	if strings.HasSuffix(word, "eed") {
+		if measure(word[:len(word)-3]) > 0 {
+			return word[:len(word)-1]
+		}
+		return word
+	}
+
+	var stem string
+	switch {
+	case strings.HasSuffix(word, "ed") && containsVowel(word[:len(word)-2]):
+		stem = word[:len(word)-2]
+	case strings.HasSuffix(word, "ing") && containsVowel(word[:len(word)-3]):
+		stem = word[:len(word)-3]
+	default:
+		return word
+	}
+
+	switch {
+	case strings.HasSuffix(stem, "at"), strings.HasSuffix(stem, "bl"), strings.HasSuffix(stem, "iz"):
+		return stem + "e"
+	case endsDoubleConsonant(stem):
+		if last := stem[len(stem)-1]; last != 'l' && last != 's' && last != 'z' {
+			return stem[:len(stem)-1]
+		}
+	case measure(stem) == 1 && endsCVC(stem):
+		return stem + "e"
+	}
+	return stem
+}
+
+// terminal y becomes i when the stem has a vowel
+func porterStep1c(word string) string {
+	if strings.HasSuffix(word, "y") && containsVowel(word[:len(word)-1]) {
+		return word[:len(word)-1] + "i"
+	}
+	return word
+}
+
+var porterStep2Rules = [][2]string{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"}, {"izer", "ize"},
+	{"bli", "ble"}, {"alli", "al"}, {"entli", "ent"}, {"eli", "e"}, {"ousli", "ous"},
+	{"ization", "ize"}, {"ation", "ate"}, {"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"},
+	{"fulness", "ful"}, {"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+	{"logi", "log"},
+}
+
+// double suffixes such as -ational and -ization are reduced to single ones
+func porterStep2(word string) string {
+	return replaceSuffix(word, porterStep2Rules, 0)
+}
+
+var porterStep3Rules = [][2]string{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"}, {"ical", "ic"},
+	{"ful", ""}, {"ness", ""},
+}
+
+// -ic-, -full and -ness endings
+func porterStep3(word string) string {
+	return replaceSuffix(word, porterStep3Rules, 0)
+}
+
+var porterStep4Rules = [][2]string{
+	{"al", ""}, {"ance", ""}, {"ence", ""}, {"er", ""}, {"ic", ""}, {"able", ""}, {"ible", ""},
+	{"ant", ""}, {"ement", ""}, {"ment", ""}, {"ent", ""}, {"ion", ""}, {"ou", ""}, {"ism", ""},
+	{"ate", ""}, {"iti", ""}, {"ous", ""}, {"ive", ""}, {"ize", ""},
+}
+
+// removes the remaining suffixes from stems with a measure above one
+func porterStep4(word string) string {
+	for _, rule := range porterStep4Rules {
+		if !strings.HasSuffix(word, rule[0]) {
+			continue
+		}
+		stem := word[:len(word)-len(rule[0])]
+		// -ion is only removed after s or t
+		if rule[0] == "ion" && !strings.HasSuffix(stem, "s") && !strings.HasSuffix(stem, "t") {
+			return word
+		}
+		if measure(stem) > 1 {
+			return stem
+		}
+		return word
+	}
+	return word
+}
+
+// removes a final e and reduces a final ll on long stems
+func porterStep5(word string) string {
+	if strings.HasSuffix(word, "e") {
+		stem := word[:len(word)-1]
+		if m := measure(stem); m > 1 || (m == 1 && !endsCVC(stem)) {
+			word = stem
+		}
+	}
+	if strings.HasSuffix(word, "ll") && measure(word) > 1 {
+		word = word[:len(word)-1]
+	}
+	return word
+}
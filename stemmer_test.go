@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+)
+
+/*
+This file tests for:
+- porter stems for each step of the algorithm
+- short words are left alone
+- stemming phrases
+- stripping only inflections from phrases
+*/
+func TestStem(t *testing.T) {
+	// examples from the Porter paper and common keywords
+	cases := map[string]string{
+		"caresses":       "caress",
+		"ponies":         "poni",
+		"cats":           "cat",
+		"feed":           "feed",
+		"agreed":         "agre",
+		"plastered":      "plaster",
+		"motoring":       "motor",
+		"sing":           "sing",
+		"conflated":      "conflat",
+		"troubled":       "troubl",
+		"sized":          "size",
+		"hopping":        "hop",
+		"falling":        "fall",
+		"hissing":        "hiss",
+		"filing":         "file",
+		"happy":          "happi",
+		"relational":     "relat",
+		"conditional":    "condit",
+		"generalization": "gener",
+		"electrical":     "electr",
+		"adjustment":     "adjust",
+		"hopefulness":    "hope",
+		"controll":       "control",
+		"functions":      "function",
+		"function":       "function",
+		"types":          "type",
+		"classes":        "class",
+		"Haskell":        "haskel",
+		"go":             "go",
+	}
+
+	for word, expected := range cases {
+		if got := stem(word); got != expected {
+			t.Errorf("Expected stem of '%s' to be '%s', got '%s'", word, expected, got)
+		}
+	}
+}
+
+func TestStemPhrase(t *testing.T) {
+	if got := stemPhrase("Type  Classes"); got != "type class" {
+		t.Errorf("Expected 'type class', got '%s'", got)
+	}
+	if got := stemPhrase("side-effects"); got != "side effect" {
+		t.Errorf("Expected 'side effect', got '%s'", got)
+	}
+}
+
+func TestInflectionStem(t *testing.T) {
+	if got := inflectionStem("Compilers"); got != "compiler" {
+		t.Errorf("Expected 'compiler', got '%s'", got)
+	}
+	if got := inflectionStem("functional languages"); got != "functional language" {
+		t.Errorf("Expected 'functional language', got '%s'", got)
+	}
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SweepConfig is one point in a parameter sweep
+// Window is the largest n-gram promoted to a keyword, 1 turns collocations off
+type SweepConfig struct {
+	Algorithm     string `json:"algorithm"`
+	MinWordLength int    `json:"min_length"`
+	Window        int    `json:"window"`
+	Weighting     string `json:"weighting"`
+}
+
+// SweepResult pairs a configuration with its evaluation report
+type SweepResult struct {
+	Config SweepConfig `json:"config"`
+	Report EvalReport  `json:"report"`
+}
+
+// weightingSchemes maps the names accepted by the sweep to positional weightings, none turns weighting off
+var weightingSchemes = map[string]*PositionWeighting{
+	"none":            nil,
+	"linear":          {FirstOccurrence: linearDecay(0.25)},
+	"exponential":     {FirstOccurrence: exponentialDecay(1)},
+	"inverse":         {FirstOccurrence: inverseDecay(2)},
+	"first-paragraph": {FirstParagraphBoost: 1.5},
+}
+
+// builds the extraction options for a configuration, starting from its algorithm
+func (c SweepConfig) options() (ExtractOptions, error) {
+	opts, err := newAlgorithmOptions(c.Algorithm, 0)
+	if err != nil {
+		return ExtractOptions{}, err
+	}
+	if c.MinWordLength < 1 {
+		return ExtractOptions{}, errors.New("minimum word length must be at least 1")
+	}
+	opts.MinWordLength = c.MinWordLength
+
+	// the window replaces the algorithm's own n-gram size, keeping its other collocation settings
+	switch {
+	case c.Window == 1:
+		opts.Collocations = nil
+	case c.Window == 2 || c.Window == 3:
+		collocations := CollocationOptions{MinCount: 2, Measure: "llr", Threshold: 10.83}
+		if opts.Collocations != nil {
+			collocations = *opts.Collocations
+		}
+		collocations.MaxN = c.Window
+		opts.Collocations = &collocations
+	default:
+		return ExtractOptions{}, errors.New("window must be 1, 2 or 3")
+	}
+
+	weighting, ok := weightingSchemes[c.Weighting]
+	if !ok {
+		return ExtractOptions{}, errors.New("unknown weighting scheme: " + c.Weighting)
+	}
+	// the weighting replaces the algorithm's own, so "none" turns it off
+	opts.Position = weighting
+
+	return opts, nil
+}
+
+// builds every combination of the given values
+func sweepGrid(algorithms []string, minLengths, windows []int, weightings []string) []SweepConfig {
+	var grid []SweepConfig
+	for _, algorithm := range algorithms {
+		for _, minLength := range minLengths {
+			for _, window := range windows {
+				for _, weighting := range weightings {
+					grid = append(grid, SweepConfig{
+						Algorithm:     algorithm,
+						MinWordLength: minLength,
+						Window:        window,
+						Weighting:     weighting,
+					})
+				}
+			}
+		}
+	}
+	return grid
+}
+
+// evaluates every configuration over the documents and ranks them by metric at rankK, best first
+// metric is one of precision, recall, f1, ndcg or map, rankK must be one of ks unless metric is map
+func runSweep(docs []Document, grid []SweepConfig, ks []int, metric string, rankK int) ([]SweepResult, error) {
+	score, err := sweepMetric(metric, ks, rankK)
+	if err != nil {
+		return nil, err
+	}
+
+	// load the stopwords once for the whole sweep
+	stopwords, err := LoadStopwords(defaultStopwordsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]SweepResult, 0, len(grid))
+	for _, config := range grid {
+		opts, err := config.options()
+		if err != nil {
+			return nil, err
+		}
+		opts.Stopwords = stopwords
+
+		report, err := evaluateDocuments(docs, opts, ks)
+		if err != nil {
+			return nil, err
+		}
+		report.Algorithm = config.Algorithm
+		results = append(results, SweepResult{Config: config, Report: report})
+	}
+
+	// stable so equal scores keep grid order
+	sort.SliceStable(results, func(i, j int) bool {
+		return score(results[i].Report) > score(results[j].Report)
+	})
+	return results, nil
+}
+
+// returns a function reading the named metric at k from a report
+func sweepMetric(metric string, ks []int, k int) (func(EvalReport) float64, error) {
+	if metric == "map" {
+		return func(r EvalReport) float64 { return r.MAP }, nil
+	}
+
+	index := -1
+	for i, value := range ks {
+		if value == k {
+			index = i
+		}
+	}
+	if index == -1 {
+		return nil, errors.New("rank k " + strconv.Itoa(k) + " is not one of the evaluated k values")
+	}
+
+	switch metric {
+	case "precision":
+		return func(r EvalReport) float64 { return r.AtK[index].Precision }, nil
+	case "recall":
+		return func(r EvalReport) float64 { return r.AtK[index].Recall }, nil
+	case "f1":
+		return func(r EvalReport) float64 { return r.AtK[index].F1 }, nil
+	case "ndcg":
+		return func(r EvalReport) float64 { return r.AtK[index].NDCG }, nil
+	}
+	return nil, errors.New("unknown metric: " + metric)
+}
+
+// the column names shared by the table and the CSV
+func sweepHeader(ks []int) []string {
+	header := []string{"rank", "algorithm", "min_length", "window", "weighting"}
+	for _, k := range ks {
+		n := strconv.Itoa(k)
+		header = append(header, "p@"+n, "r@"+n, "f1@"+n, "ndcg@"+n)
+	}
+	return append(header, "map")
+}
+
+// the values for one result in the same order as sweepHeader
+func sweepRow(rank int, result SweepResult) []string {
+	format := func(v float64) string { return strconv.FormatFloat(v, 'f', 4, 64) }
+	row := []string{
+		strconv.Itoa(rank),
+		result.Config.Algorithm,
+		strconv.Itoa(result.Config.MinWordLength),
+		strconv.Itoa(result.Config.Window),
+		result.Config.Weighting,
+	}
+	for _, m := range result.Report.AtK {
+		row = append(row, format(m.Precision), format(m.Recall), format(m.F1), format(m.NDCG))
+	}
+	return append(row, format(result.Report.MAP))
+}
+
+// writes ranked sweep results in one of the formats accepted by newResultWriter
+// plain writes one configuration per line, best first
+func writeSweepReport(w io.Writer, format string, results []SweepResult, ks []int) error {
+	writer, err := newResultWriter(format)
+	if err != nil {
+		return err
+	}
+
+	if results == nil {
+		results = []SweepResult{}
+	}
+	records := Records{Columns: sweepHeader(ks), Value: results}
+	for i, result := range results {
+		row := sweepRow(i+1, result)
+		records.Rows = append(records.Rows, row)
+		records.Lines = append(records.Lines, strings.Join(row[1:5], " "))
+	}
+	return writer.WriteRecords(w, records)
+}
+
+// splits a comma separated list of names, dropping empty entries
+func parseNameList(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// runSweepCommand is the sweep command, it evaluates a grid of configurations over a dataset
+func runSweepCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("sweep", flag.ContinueOnError)
+	dataset := flags.String("dataset", "", "JSONL file or SemEval/Inspec directory of documents with gold keywords")
+	algorithmList := flags.String("algorithms", "tf", "comma separated algorithms: "+strings.Join(algorithmNames(), ", "))
+	minLengthList := flags.String("min-length", "3", "comma separated minimum word lengths")
+	windowList := flags.String("window", "1", "comma separated largest n-gram sizes, 1 turns collocations off")
+	weightingList := flags.String("weighting", "none", "comma separated weighting schemes: none, linear, exponential, inverse, first-paragraph")
+	kList := flags.String("k", "5,10,15", "comma separated cut-offs to report metrics at")
+	metric := flags.String("metric", "f1", "metric to rank by: precision, recall, f1, ndcg or map")
+	rankK := flags.Int("rank-k", 0, "cut-off to rank by, defaults to the largest k")
+	csvPath := flags.String("csv", "", "also write the ranked results to this CSV file")
+	format := flags.String("format", "table", "output format: "+strings.Join(outputFormatNames(), ", "))
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *dataset == "" {
+		return errors.New("sweep needs a -dataset")
+	}
+	if _, err := newResultWriter(*format); err != nil {
+		return err
+	}
+
+	ks, err := parseIntList(*kList)
+	if err != nil {
+		return err
+	}
+	minLengths, err := parseIntList(*minLengthList)
+	if err != nil {
+		return err
+	}
+	windows, err := parseIntList(*windowList)
+	if err != nil {
+		return err
+	}
+	if *rankK == 0 {
+		for _, k := range ks {
+			*rankK = max(*rankK, k)
+		}
+	}
+
+	docs, err := loadDocuments(*dataset)
+	if err != nil {
+		return err
+	}
+
+	grid := sweepGrid(parseNameList(*algorithmList), minLengths, windows, parseNameList(*weightingList))
+	results, err := runSweep(docs, grid, ks, *metric, *rankK)
+	if err != nil {
+		return err
+	}
+
+	if *csvPath != "" {
+		file, err := os.Create(*csvPath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if err := writeSweepReport(file, "csv", results, ks); err != nil {
+			return err
+		}
+	}
+
+	return writeSweepReport(stdout, *format, results, ks)
+}
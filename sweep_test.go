@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/*
+This file tests for:
+- building the grid of configurations
+- turning a configuration into extraction options
+- rejecting bad configurations and metrics
+- the minimum word length is respected during extraction
+- ranking results by the chosen metric
+- the sweep command writing a table, a CSV and other output formats
+*/
+func TestSweepGrid(t *testing.T) {
+	grid := sweepGrid([]string{"tf", "collocation"}, []int{3, 4}, []int{1, 2, 3}, []string{"none"})
+	if len(grid) != 12 {
+		t.Fatalf("Expected 12 configurations, got %d", len(grid))
+	}
+	if grid[0] != (SweepConfig{Algorithm: "tf", MinWordLength: 3, Window: 1, Weighting: "none"}) {
+		t.Errorf("Unexpected first configuration: %+v", grid[0])
+	}
+}
+
+func TestSweepConfigOptions(t *testing.T) {
+	// Test window and weighting are applied on top of the algorithm
+	t.Run("Options", func(t *testing.T) {
+		opts, err := SweepConfig{Algorithm: "tf", MinWordLength: 4, Window: 2, Weighting: "exponential"}.options()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if opts.MinWordLength != 4 {
+			t.Errorf("Expected min word length 4, got %d", opts.MinWordLength)
+		}
+		if opts.Collocations == nil || opts.Collocations.MaxN != 2 {
+			t.Errorf("Expected bigram collocations, got %+v", opts.Collocations)
+		}
+		if opts.Position == nil {
+			t.Error("Expected positional weighting to be set")
+		}
+
+		// a window of one turns off the collocation algorithm's n-grams
+		opts, _ = SweepConfig{Algorithm: "collocation", MinWordLength: 3, Window: 1, Weighting: "none"}.options()
+		if opts.Collocations != nil {
+			t.Error("Expected window 1 to turn collocations off")
+		}
+
+		// no weighting turns off the algorithm's own positional weighting
+		opts, _ = SweepConfig{Algorithm: "tf-position", MinWordLength: 3, Window: 1, Weighting: "none"}.options()
+		if opts.Position != nil {
+			t.Error("Expected weighting none to turn positional weighting off")
+		}
+	})
+
+	// Test bad configurations are rejected
+	t.Run("Invalid", func(t *testing.T) {
+		bad := []SweepConfig{
+			{Algorithm: "bogus", MinWordLength: 3, Window: 1, Weighting: "none"},
+			{Algorithm: "tf", MinWordLength: 0, Window: 1, Weighting: "none"},
+			{Algorithm: "tf", MinWordLength: 3, Window: 4, Weighting: "none"},
+			{Algorithm: "tf", MinWordLength: 3, Window: 1, Weighting: "cubic"},
+		}
+		for _, config := range bad {
+			if _, err := config.options(); err == nil {
+				t.Errorf("Expected error for %+v, got nil", config)
+			}
+		}
+	})
+
+	// Test the minimum word length reaches getTokenCount
+	t.Run("MinWordLength", func(t *testing.T) {
+		keywords, err := extractKeywords("cat cat cat horse", ExtractOptions{NumKeywords: 5, Stopwords: map[string]struct{}{}, MinWordLength: 4})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(keywords) != 1 || keywords[0].Term != "horse" {
+			t.Errorf("Expected only 'horse', got %v", keywordTerms(keywords))
+		}
+	})
+}
+
+func TestRunSweep(t *testing.T) {
+	docs := []Document{
+		{ID: "1", Text: "ox ox ox horse", Keywords: []string{"ox"}},
+	}
+	grid := []SweepConfig{
+		{Algorithm: "tf", MinWordLength: 3, Window: 1, Weighting: "none"},
+		{Algorithm: "tf", MinWordLength: 2, Window: 1, Weighting: "none"},
+	}
+
+	results, err := runSweep(docs, grid, []int{1}, "f1", 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	// only a minimum length of 2 lets 'ox' be found
+	if results[0].Config.MinWordLength != 2 {
+		t.Errorf("Expected min length 2 to rank first, got %+v", results[0].Config)
+	}
+
+	if _, err := runSweep(docs, grid, []int{1}, "f1", 5); err == nil {
+		t.Error("Expected error for rank k not evaluated, got nil")
+	}
+	if _, err := runSweep(docs, grid, []int{1}, "accuracy", 1); err == nil {
+		t.Error("Expected error for unknown metric, got nil")
+	}
+}
+
+func TestRunSweepCommand(t *testing.T) {
+	dir := t.TempDir()
+	dataset := filepath.Join(dir, "dataset.jsonl")
+	content := `{"id":"a","text":"Haskell is a lazy language. Haskell has lazy evaluation.","keywords":["haskell"]}` + "\n"
+	if err := os.WriteFile(dataset, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	csvPath := filepath.Join(dir, "sweep.csv")
+
+	var out bytes.Buffer
+	err := runSweepCommand([]string{"-dataset", dataset, "-min-length", "3,4", "-weighting", "none,linear", "-k", "1,3", "-csv", csvPath}, &out)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "rank") {
+		t.Errorf("Expected a table header, got:\n%s", out.String())
+	}
+
+	file, err := os.Open(csvPath)
+	if err != nil {
+		t.Fatalf("Expected CSV file to be written: %v", err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected valid CSV, got: %v", err)
+	}
+	// header plus four configurations
+	if len(rows) != 5 {
+		t.Errorf("Expected 5 rows, got %d", len(rows))
+	}
+
+	// Test -format writes one configuration per line for plain
+	out.Reset()
+	if err := runSweepCommand([]string{"-dataset", dataset, "-k", "1", "-format", "plain"}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if out.String() != "tf 3 1 none\n" {
+		t.Errorf("Expected 'tf 3 1 none', got %q", out.String())
+	}
+	if err := runSweepCommand([]string{"-dataset", dataset, "-format", "xml"}, &out); err == nil {
+		t.Error("Expected error for unknown format, got nil")
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SynonymOptions merges the counts of terms that mean the same thing before keywords are ranked
+// Dictionary maps each alias to the term it is counted as, both lowercased with single spaces between words
+// DetectAcronyms adds "long form (ACR)" pairs found in the text, counting the acronym as its long form
+// entries in Dictionary win over detected acronyms
+type SynonymOptions struct {
+	Dictionary     map[string]string
+	DetectAcronyms bool
+}
+
+// SynonymConfig names synonym files so they can be set in a config file
+type SynonymConfig struct {
+	Files    []string `json:"files"`    // synonym files, see loadSynonyms
+	Acronyms bool     `json:"acronyms"` // detect acronyms defined in the text
+}
+
+// an acronym of two to ten capitals or digits in brackets, starting with a capital, an s may pluralise it
+var acronymPattern = regexp.MustCompile(`\(([A-Z][A-Z0-9]{1,9})s?\)`)
+
+// small words a long form may include without them giving the acronym a letter, as in "Bureau of Investigation"
+var acronymFillers = map[string]struct{}{
+	"a": {}, "an": {}, "and": {}, "for": {}, "in": {}, "of": {}, "on": {}, "the": {}, "to": {},
+}
+
+// loadSynonyms reads a synonym file in the Solr format, one rule per line
+// "ghc, glasgow haskell compiler" makes every term on the line count as the first one
+// "ghc, ghci => glasgow haskell compiler" makes the terms on the left count as the one on the right
+// blank lines and lines starting with # are skipped
+func loadSynonyms(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.New("Error opening file, check file path: " + err.Error())
+	}
+	defer file.Close()
+
+	dictionary := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var aliases []string
+		var canonical string
+		if left, right, ok := strings.Cut(line, "=>"); ok {
+			aliases = splitSynonyms(left)
+			if targets := splitSynonyms(right); len(targets) == 1 {
+				canonical = targets[0]
+			} else {
+				return nil, errors.New(path + ":" + strconv.Itoa(number) + ": expected one term after =>")
+			}
+		} else {
+			terms := splitSynonyms(line)
+			if len(terms) < 2 {
+				return nil, errors.New(path + ":" + strconv.Itoa(number) + ": expected at least two terms")
+			}
+			canonical, aliases = terms[0], terms[1:]
+		}
+		for _, alias := range aliases {
+			if alias != canonical {
+				dictionary[alias] = canonical
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return dictionary, nil
+}
+
+// splits a comma separated list of terms, normalising each and dropping empty ones
+func splitSynonyms(list string) []string {
+	var terms []string
+	for _, term := range strings.Split(list, ",") {
+		if term = normaliseTerm(term); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// detectAcronyms finds acronyms defined in brackets after their long form, such as
+// "Glasgow Haskell Compiler (GHC)", and maps each lowercased acronym to its lowercased long form
+// the long form is the words before the bracket whose initials spell the acronym, small words such as "of"
+// may sit between them
+func detectAcronyms(content string) map[string]string {
+	acronyms := make(map[string]string)
+	for _, match := range acronymPattern.FindAllStringSubmatchIndex(content, -1) {
+		acronym := content[match[2]:match[3]]
+		// a long form is a few words, so only the text just before the bracket is looked at
+		words := strings.FieldsFunc(content[max(0, match[0]-200):match[0]], func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+		})
+		if longForm := acronymLongForm(acronym, words); longForm != "" {
+			acronyms[strings.ToLower(acronym)] = longForm
+		}
+	}
+	return acronyms
+}
+
+// matches the letters of an acronym against the initials of the words before it, last letter first
+// returns the long form, or an empty string when the words do not spell the acronym
+func acronymLongForm(acronym string, words []string) string {
+	letters := []rune(strings.ToLower(acronym))
+	next := len(letters) - 1
+	start := len(words)
+	for i := len(words) - 1; i >= 0 && next >= 0; i-- {
+		word := strings.ToLower(words[i])
+		initial := []rune(word)[0]
+		switch {
+		case initial == letters[next]:
+			next--
+			start = i
+		case next < len(letters)-1 && isAcronymFiller(word):
+			// fillers only count inside the long form, not between it and the bracket
+		default:
+			return ""
+		}
+	}
+	if next >= 0 {
+		return ""
+	}
+	return strings.ToLower(strings.Join(words[start:], " "))
+}
+
+// reports whether a word may sit inside a long form without a letter of its own
+func isAcronymFiller(word string) bool {
+	_, ok := acronymFillers[word]
+	return ok
+}
+
+// returns the dictionary used for content, with the acronyms it defines added when asked for
+func (opts SynonymOptions) dictionary(content string) map[string]string {
+	if !opts.DetectAcronyms {
+		return opts.Dictionary
+	}
+	merged := detectAcronyms(content)
+	for alias, canonical := range opts.Dictionary {
+		merged[alias] = canonical
+	}
+	return merged
+}
+
+// follows a term through the dictionary to the term it is finally counted as, stopping at a loop
+func canonicalTerm(dictionary map[string]string, term string) string {
+	seen := map[string]struct{}{term: {}}
+	for {
+		next, ok := dictionary[term]
+		if !ok {
+			return term
+		}
+		if _, loop := seen[next]; loop {
+			return term
+		}
+		seen[next] = struct{}{}
+		term = next
+	}
+}
+
+// promoteSynonymPhrases counts every multi-word term of the dictionary found in tokens as a term of its own,
+// taking its count off the words it is made of as promoteCollocations does, so "glasgow haskell compiler"
+// is ranked as one term rather than as glasgow, haskell and compiler
+// longer phrases are counted first and each token counts towards one phrase at most, phrases that are
+// already terms or overlap a term of several words are left alone
+func promoteSynonymPhrases(tfi TermFrequencyIndex, tci TermCountIndex, tokens []Token, dictionary map[string]string, totalWords int) {
+	var existing []string
+	for term := range tci {
+		if strings.Contains(term, " ") {
+			existing = append(existing, term)
+		}
+	}
+
+	seen := make(map[string]struct{})
+	var phrases [][]string
+	for alias, canonical := range dictionary {
+		for _, term := range []string{alias, canonical} {
+			words := strings.Fields(term)
+			if _, ok := seen[term]; ok || len(words) < 2 || overlapsAny(term, existing) {
+				continue
+			}
+			seen[term] = struct{}{}
+			phrases = append(phrases, words)
+		}
+	}
+	sort.Slice(phrases, func(i, j int) bool {
+		if len(phrases[i]) != len(phrases[j]) {
+			return len(phrases[i]) > len(phrases[j])
+		}
+		return strings.Join(phrases[i], " ") < strings.Join(phrases[j], " ")
+	})
+
+	used := make([]bool, len(tokens))
+	for _, words := range phrases {
+		count := 0
+		for i := 0; i < len(tokens); i++ {
+			if !matchesAt(tokens, i, words) || slices.Contains(used[i:i+len(words)], true) {
+				continue
+			}
+			for j := i; j < i+len(words); j++ {
+				used[j] = true
+			}
+			count++
+		}
+		if count == 0 {
+			continue
+		}
+
+		term := strings.Join(words, " ")
+		tci[term] = count
+		tfi[term] = float64(count*len(words)) / float64(totalWords)
+		for _, word := range words {
+			if _, ok := tci[word]; !ok {
+				// stopwords such as "of" were never counted
+				continue
+			}
+			tci[word] -= count
+			if tci[word] <= 0 {
+				delete(tci, word)
+				delete(tfi, word)
+				continue
+			}
+			tfi[word] = float64(tci[word]) / float64(totalWords)
+		}
+	}
+}
+
+// mergeSynonyms moves the frequency and count of every alias found in tfi onto the term it stands for,
+// which is added if it was not a term already
+func mergeSynonyms(tfi TermFrequencyIndex, tci TermCountIndex, dictionary map[string]string) {
+	for alias := range dictionary {
+		freq, ok := tfi[alias]
+		canonical := canonicalTerm(dictionary, alias)
+		if !ok || alias == canonical {
+			continue
+		}
+		tfi[canonical] += freq
+		tci[canonical] += tci[alias]
+		delete(tfi, alias)
+		delete(tci, alias)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+/*
+This file tests for:
+- reading Solr style synonym files
+- detecting acronyms defined in brackets after their long form
+- merging the counts of aliases onto the term they stand for, following chains
+- counting long forms as single terms and taking them off their words
+- merging synonyms during extraction
+- setting synonyms in a config file
+*/
+
+func TestLoadSynonyms(t *testing.T) {
+	path := writeConfigFile(t, "synonyms.txt", "# languages\nGHC, Glasgow  Haskell Compiler\n\nghci, runghc => ghc\nfp,fp\n")
+	dictionary, err := loadSynonyms(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := map[string]string{
+		"glasgow haskell compiler": "ghc",
+		"ghci":                     "ghc",
+		"runghc":                   "ghc",
+	}
+	if !reflect.DeepEqual(dictionary, expected) {
+		t.Errorf("Expected %v, got %v", expected, dictionary)
+	}
+
+	for _, content := range []string{"ghc\n", "a, b => c, d\n"} {
+		if _, err := loadSynonyms(writeConfigFile(t, "bad.txt", content)); err == nil {
+			t.Errorf("Expected error for %q, got nil", content)
+		}
+	}
+}
+
+func TestDetectAcronyms(t *testing.T) {
+	content := "It runs on the Glasgow Haskell Compiler (GHC). The Federal Bureau of Investigation (FBI) and " +
+		"Application Programming Interfaces (APIs) too, but not a Random Guess (XYZ) or (A) or (TODO."
+	expected := map[string]string{
+		"ghc": "glasgow haskell compiler",
+		"fbi": "federal bureau of investigation",
+		"api": "application programming interfaces",
+	}
+	if acronyms := detectAcronyms(content); !reflect.DeepEqual(acronyms, expected) {
+		t.Errorf("Expected %v, got %v", expected, acronyms)
+	}
+}
+
+func TestMergeSynonyms(t *testing.T) {
+	tfi := TermFrequencyIndex{"ghc": 0.2, "ghci": 0.1, "haskell": 0.3, "glasgow haskell compiler": 0.05}
+	tci := TermCountIndex{"ghc": 4, "ghci": 2, "haskell": 6, "glasgow haskell compiler": 1}
+	mergeSynonyms(tfi, tci, map[string]string{
+		"ghci":   "ghc",
+		"ghc":    "glasgow haskell compiler",
+		"loop":   "back",
+		"back":   "loop",
+		"absent": "haskell",
+	})
+
+	expectedCounts := TermCountIndex{"haskell": 6, "glasgow haskell compiler": 7}
+	if !reflect.DeepEqual(tci, expectedCounts) {
+		t.Errorf("Expected %v, got %v", expectedCounts, tci)
+	}
+	if len(tfi) != 2 || tfi["glasgow haskell compiler"] < 0.3499 || tfi["glasgow haskell compiler"] > 0.3501 {
+		t.Errorf("Expected the frequencies to be added, got %v", tfi)
+	}
+}
+
+func TestExtractSynonyms(t *testing.T) {
+	content := "The Glasgow Haskell Compiler (GHC) is fast. GHC compiles Haskell. Developers love GHC and ghci."
+	opts := ExtractOptions{
+		NumKeywords: 10,
+		Stopwords:   map[string]struct{}{"the": {}, "and": {}},
+		Synonyms:    &SynonymOptions{Dictionary: map[string]string{"ghci": "ghc"}, DetectAcronyms: true},
+	}
+	keywords, err := extractKeywords(content, opts)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	// the long form once, ghc three times and ghci once
+	if keywords[0].Term != "glasgow haskell compiler" || keywords[0].Count != 5 {
+		t.Errorf("Expected glasgow haskell compiler counted 5 times first, got %+v", keywords)
+	}
+	// the words of the long form only keep the counts from outside it
+	for _, keyword := range keywords {
+		switch keyword.Term {
+		case "glasgow", "compiler", "ghc", "ghci":
+			t.Errorf("Expected %s to be merged, got %+v", keyword.Term, keyword)
+		case "haskell":
+			if keyword.Count != 1 {
+				t.Errorf("Expected haskell counted once, got %d", keyword.Count)
+			}
+		}
+	}
+}
+
+func TestPromoteSynonymPhrases(t *testing.T) {
+	tokens := tokenize("federal bureau of investigation agents and the bureau", defaultWordSplitter)
+	tci := TermCountIndex{"federal": 1, "bureau": 2, "investigation": 1, "agents": 1}
+	tfi := TermFrequencyIndex{"federal": 0.125, "bureau": 0.25, "investigation": 0.125, "agents": 0.125}
+
+	promoteSynonymPhrases(tfi, tci, tokens, map[string]string{"fbi": "federal bureau of investigation"}, 8)
+
+	expected := TermCountIndex{"federal bureau of investigation": 1, "bureau": 1, "agents": 1}
+	if !reflect.DeepEqual(tci, expected) {
+		t.Errorf("Expected %v, got %v", expected, tci)
+	}
+	if tfi["federal bureau of investigation"] != 0.5 || tfi["bureau"] != 0.125 {
+		t.Errorf("Expected the phrase frequency to replace its words, got %v", tfi)
+	}
+}
+
+func TestSynonymConfig(t *testing.T) {
+	synonyms := writeConfigFile(t, "synonyms.txt", "ghci => ghc\n")
+	path := writeConfigFile(t, "config.json", `{"synonyms": {"files": [`+strconv.Quote(synonyms)+`], "acronyms": true}}`)
+	config, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	opts, err := config.options()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if opts.Synonyms == nil || !opts.Synonyms.DetectAcronyms || opts.Synonyms.Dictionary["ghci"] != "ghc" {
+		t.Errorf("Unexpected synonym options: %+v", opts.Synonyms)
+	}
+
+	missing := writeConfigFile(t, "missing.json", `{"synonyms": {"files": ["missing.txt"]}}`)
+	if _, err := loadConfig(missing); err == nil {
+		t.Error("Expected error for a missing synonym file, got nil")
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// TextFilter rewrites raw text before it is split into words
+// filters write spaces over what they remove so the text keeps its length, and offsets into it are offsets into the original
+type TextFilter func(content string) string
+
+// FilterChain is an ordered list of text filters that are applied one after another
+type FilterChain []TextFilter
+
+var (
+	citationPattern      = regexp.MustCompile(`\[(?:\d+(?:\s*[,–-]\s*\d+)*|[a-z]|citation needed|note \d+)\]`)
+	urlPattern           = regexp.MustCompile(`(?i)\b(?:https?://|ftp://|www\.)[^\s<>"]+`)
+	emailPattern         = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)+`)
+	slashedPattern       = regexp.MustCompile(`/[^/\n]{1,40}/`)
+	parentheticalPattern = regexp.MustCompile(`\(\s*(?:(?i:see|cf\.|e\.g\.)\s[^()]*|[A-Z][A-Za-z-]+(?: et al\.)?,? \d{4}[a-z]?(?:, p+\. ?\d+)?|[\s\p{P}]*)\)`)
+)
+
+// textFilters maps the names used in configuration to the filters they refer to
+var textFilters = map[string]TextFilter{
+	"urls":           removeURLs,
+	"emails":         removeEmails,
+	"citations":      removeCitations,
+	"ipa":            removeIPA,
+	"parentheticals": removeParentheticals,
+}
+
+// defaultFilterNames is the order the filters run in when no chain is configured
+// urls and emails go first so their slashes and brackets are not picked up by later filters
+var defaultFilterNames = []string{"urls", "emails", "citations", "ipa", "parentheticals"}
+
+// Apply runs each filter in order over the content and returns the result
+func (fc FilterChain) Apply(content string) string {
+	for _, filter := range fc {
+		content = filter(content)
+	}
+	return content
+}
+
+// newFilterChain builds a chain from a list of filter names, returning an error for unknown names
+func newFilterChain(names []string) (FilterChain, error) {
+	chain := make(FilterChain, 0, len(names))
+	for _, name := range names {
+		filter, ok := textFilters[name]
+		if !ok {
+			return nil, errors.New("unknown text filter: " + name)
+		}
+		chain = append(chain, filter)
+	}
+	return chain, nil
+}
+
+// defaultFilterChain returns the chain used when extracting keywords from plain text
+func defaultFilterChain() FilterChain {
+	chain, _ := newFilterChain(defaultFilterNames)
+	return chain
+}
+
+// removes wikipedia style citation markers such as [26], [1-3] and [citation needed]
+func removeCitations(content string) string {
+	return citationPattern.ReplaceAllStringFunc(content, blankOut)
+}
+
+// removes http, ftp and www links
+func removeURLs(content string) string {
+	return urlPattern.ReplaceAllStringFunc(content, blankOut)
+}
+
+// removes email addresses
+func removeEmails(content string) string {
+	return emailPattern.ReplaceAllStringFunc(content, blankOut)
+}
+
+// letters used in IPA transcriptions but not in ordinary English or accented Latin text
+const ipaLetters = "æðøŋθβχœɸʃʒ"
+
+// reports whether a rune is an IPA symbol: an IPA extension, a modifier letter such as the stress and length
+// marks, a combining diacritic or one of ipaLetters
+func isIPARune(r rune) bool {
+	return (r >= 0x0250 && r <= 0x036F) || strings.ContainsRune(ipaLetters, r)
+}
+
+// removes IPA pronunciations such as /hæskəl/
+// only text between slashes holding IPA symbols is removed, so paths, fractions, dates and "and/or" are kept
+func removeIPA(content string) string {
+	var out strings.Builder
+	last := 0
+	for i := 0; i < len(content); {
+		// every slash is tried as an opening one, so an ascii span such as "a/b then /" does not hide
+		// the pronunciation that follows it
+		next := strings.IndexByte(content[i:], '/')
+		if next == -1 {
+			break
+		}
+		start := i + next
+		match := slashedPattern.FindStringIndex(content[start:])
+		if match == nil || match[0] != 0 || strings.IndexFunc(content[start:start+match[1]], isIPARune) == -1 {
+			i = start + 1
+			continue
+		}
+		out.WriteString(content[last:start])
+		out.WriteString(blankOut(content[start : start+match[1]]))
+		last = start + match[1]
+		i = last
+	}
+	out.WriteString(content[last:])
+	return out.String()
+}
+
+// removes parenthetical references such as (see below), (Smith, 2010) and
+// brackets left empty by earlier filters
+func removeParentheticals(content string) string {
+	return parentheticalPattern.ReplaceAllStringFunc(content, blankOut)
+}
+
+// returns as many spaces as text has bytes
+func blankOut(text string) string {
+	return strings.Repeat(" ", len(text))
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+/*
+This file tests for:
+- removing citation markers
+- removing urls and emails
+- removing IPA pronunciations while keeping ascii paths
+- removing parenthetical references and emptied brackets
+- building a chain from names and rejecting unknown names
+- the default chain on wikipedia style text keeps the length of the text
+- benchmark for the default filter chain
+*/
+func TestTextFilters(t *testing.T) {
+	// Test citation markers are removed
+	t.Run("Citations", func(t *testing.T) {
+		content := "lazy evaluation.[26][27] Haskell[1-3] is used[citation needed] widely[a]"
+		result := removeCitations(content)
+
+		for _, marker := range []string{"[26]", "[27]", "[1-3]", "[citation needed]", "[a]"} {
+			if strings.Contains(result, marker) {
+				t.Errorf("Expected marker '%s' to be removed, got: %q", marker, result)
+			}
+		}
+		if !strings.Contains(result, "Haskell") {
+			t.Errorf("Expected surrounding text to be kept, got: %q", result)
+		}
+	})
+
+	// Test urls and emails are removed
+	t.Run("URLsAndEmails", func(t *testing.T) {
+		content := "see https://www.haskell.org/ghc or www.example.com and mail info@haskell.org today"
+		result := removeEmails(removeURLs(content))
+
+		for _, noise := range []string{"haskell.org", "example.com", "info@"} {
+			if strings.Contains(result, noise) {
+				t.Errorf("Expected '%s' to be removed, got: %q", noise, result)
+			}
+		}
+		if !strings.Contains(result, "today") {
+			t.Errorf("Expected surrounding text to be kept, got: %q", result)
+		}
+	})
+
+	// Test IPA is removed but ascii slashes are not
+	t.Run("IPA", func(t *testing.T) {
+		result := removeIPA("Haskell (/hæskəl/) has input/output and a/b/c paths")
+
+		if strings.Contains(result, "hæskəl") {
+			t.Errorf("Expected IPA to be removed, got: %q", result)
+		}
+		if !strings.Contains(result, "a/b/c") || !strings.Contains(result, "input/output") {
+			t.Errorf("Expected ascii slashes to be kept, got: %q", result)
+		}
+
+		kept := "use and/or the /usr/lib/ path, 1/2 cup on 3/4/2021, café/naïve/ and fiancée /résumé/"
+		if result := removeIPA(kept); result != kept {
+			t.Errorf("Expected text without IPA symbols to be kept, got: %q", result)
+		}
+
+		// the ascii span before the pronunciation does not hide it
+		result = removeIPA("either a/b or /ˈhæskəl/ works")
+		if result != "either a/b or "+strings.Repeat(" ", len("/ˈhæskəl/"))+" works" {
+			t.Errorf("Expected only the pronunciation to be removed, got: %q", result)
+		}
+	})
+
+	// Test parenthetical references are removed
+	t.Run("Parentheticals", func(t *testing.T) {
+		content := "Haskell ( ) was designed (see below) by a committee (Hudak, 2007) in academia (IO)"
+		result := removeParentheticals(content)
+
+		for _, ref := range []string{"( )", "(see below)", "(Hudak, 2007)"} {
+			if strings.Contains(result, ref) {
+				t.Errorf("Expected '%s' to be removed, got: %q", ref, result)
+			}
+		}
+		// ordinary parentheses that carry content should be kept
+		if !strings.Contains(result, "(IO)") {
+			t.Errorf("Expected '(IO)' to be kept, got: %q", result)
+		}
+	})
+
+	// Test chains can be built from names
+	t.Run("ChainFromNames", func(t *testing.T) {
+		chain, err := newFilterChain([]string{"citations"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if got := chain.Apply("word[1]"); strings.TrimSpace(got) != "word" {
+			t.Errorf("Expected 'word', got: %q", got)
+		}
+
+		if _, err := newFilterChain([]string{"citations", "bogus"}); err == nil {
+			t.Error("Expected error for unknown filter name, got nil")
+		}
+	})
+
+	// Test the default chain on the kind of text found in the main.go sample
+	t.Run("DefaultChain", func(t *testing.T) {
+		content := "Haskell (/hæskəl/[25]) is a purely functional programming language.[26][27]"
+		result := defaultFilterChain().Apply(content)
+
+		for _, noise := range []string{"[25]", "[26]", "hæskəl", "("} {
+			if strings.Contains(result, noise) {
+				t.Errorf("Expected '%s' to be removed, got: %q", noise, result)
+			}
+		}
+		if !strings.Contains(result, "purely functional programming language") {
+			t.Errorf("Expected prose to be kept, got: %q", result)
+		}
+		if len(result) != len(content) {
+			t.Errorf("Expected the filtered text to keep its length of %d, got %d", len(content), len(result))
+		}
+	})
+}
+
+// Benchmark test for the default filter chain
+func BenchmarkDefaultFilterChain(b *testing.B) {
+	content := "Haskell (/hæskəl/[25]) is a general-purpose, statically typed, purely functional programming language.[26][27] " +
+		"See https://haskell.org or email info@haskell.org (see below) for more."
+	chain := defaultFilterChain()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		chain.Apply(content)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// DocumentFrequencyIndex holds how many documents of a corpus each term appears in
+type DocumentFrequencyIndex map[string]int
+
+// KeywordVector is a sparse vector of term weights, scaled to unit length so a dot product is a cosine
+type KeywordVector map[string]float64
+
+// getDocumentFrequency counts the documents each term appears in
+func getDocumentFrequency(counts []TermCountIndex) DocumentFrequencyIndex {
+	df := make(DocumentFrequencyIndex)
+	for _, tci := range counts {
+		for term := range tci {
+			df[term]++
+		}
+	}
+	return df
+}
+
+// inverse document frequency smoothed as if one extra document held every term
+// so terms in every document still get a small positive weight and unseen terms do not divide by zero
+func inverseDocumentFrequency(df, documents int) float64 {
+	return math.Log(float64(1+documents)/float64(1+df)) + 1
+}
+
+// getTFIDF weights each term's frequency in a document by how rare it is across the corpus
+// documents is the number of documents df was counted over
+func getTFIDF(tci TermCountIndex, df DocumentFrequencyIndex, documents int) TermFrequencyIndex {
+	total := 0
+	for _, count := range tci {
+		total += count
+	}
+
+	tfidf := make(TermFrequencyIndex, len(tci))
+	if total == 0 {
+		return tfidf
+	}
+	for term, count := range tci {
+		tfidf[term] = float64(count) / float64(total) * inverseDocumentFrequency(df[term], documents)
+	}
+	return tfidf
+}
+
+// newKeywordVector keeps the maxTerms highest weighted terms, or every term when maxTerms is 0,
+// and scales them to unit length
+func newKeywordVector(weights TermFrequencyIndex, maxTerms int) KeywordVector {
+	terms := make([]string, 0, len(weights))
+	for term := range weights {
+		terms = append(terms, term)
+	}
+	// ties broken alphabetically so the same document always keeps the same terms
+	sort.Slice(terms, func(i, j int) bool {
+		if weights[terms[i]] != weights[terms[j]] {
+			return weights[terms[i]] > weights[terms[j]]
+		}
+		return terms[i] < terms[j]
+	})
+	if maxTerms > 0 && len(terms) > maxTerms {
+		terms = terms[:maxTerms]
+	}
+
+	norm := 0.0
+	for _, term := range terms {
+		norm += weights[term] * weights[term]
+	}
+	norm = math.Sqrt(norm)
+
+	vector := make(KeywordVector, len(terms))
+	if norm == 0 {
+		return vector
+	}
+	for _, term := range terms {
+		vector[term] = weights[term] / norm
+	}
+	return vector
+}
+
+// cosine similarity of two unit length vectors
+func cosineSimilarity(a, b KeywordVector) float64 {
+	// loop over the shorter vector
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	dot := 0.0
+	for term, weight := range a {
+		dot += weight * b[term]
+	}
+	return dot
+}
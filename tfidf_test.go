@@ -0,0 +1,61 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+/*
+This file tests for:
+- counting document frequency
+- smoothed inverse document frequency
+- TF-IDF favours terms rare across the corpus
+- keyword vectors are trimmed and unit length
+- cosine similarity
+*/
+func TestGetDocumentFrequency(t *testing.T) {
+	df := getDocumentFrequency([]TermCountIndex{
+		{"haskell": 3, "monads": 1},
+		{"haskell": 1, "rust": 2},
+	})
+	if df["haskell"] != 2 || df["monads"] != 1 || df["rust"] != 1 {
+		t.Errorf("Unexpected document frequencies: %v", df)
+	}
+}
+
+func TestGetTFIDF(t *testing.T) {
+	// a term in every document still scores above zero
+	if idf := inverseDocumentFrequency(4, 4); math.Abs(idf-1) > 0.0001 {
+		t.Errorf("Expected idf 1 for a term in every document, got %.4f", idf)
+	}
+
+	df := DocumentFrequencyIndex{"haskell": 4, "monads": 1}
+	tfidf := getTFIDF(TermCountIndex{"haskell": 1, "monads": 1}, df, 4)
+	if tfidf["monads"] <= tfidf["haskell"] {
+		t.Errorf("Expected the rarer term to score higher, got %v", tfidf)
+	}
+	expected := 0.5 * (math.Log(5.0/2.0) + 1)
+	if math.Abs(tfidf["monads"]-expected) > 0.0001 {
+		t.Errorf("Expected %.4f, got %.4f", expected, tfidf["monads"])
+	}
+}
+
+func TestKeywordVector(t *testing.T) {
+	vector := newKeywordVector(TermFrequencyIndex{"a": 3, "b": 4, "c": 1}, 2)
+	if len(vector) != 2 {
+		t.Fatalf("Expected 2 terms, got %v", vector)
+	}
+	if math.Abs(vector["a"]-0.6) > 0.0001 || math.Abs(vector["b"]-0.8) > 0.0001 {
+		t.Errorf("Expected a 0.6 and b 0.8, got %v", vector)
+	}
+
+	if score := cosineSimilarity(vector, vector); math.Abs(score-1) > 0.0001 {
+		t.Errorf("Expected a vector to be identical to itself, got %.4f", score)
+	}
+	if score := cosineSimilarity(vector, KeywordVector{"c": 1}); score != 0 {
+		t.Errorf("Expected vectors sharing no terms to score 0, got %.4f", score)
+	}
+	if len(newKeywordVector(TermFrequencyIndex{}, 0)) != 0 {
+		t.Error("Expected an empty vector for no weights")
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TokenFilter decides whether a word should be counted, returning false to drop it
+type TokenFilter func(word string) bool
+
+// TokenFilterChain counts a word only if every filter keeps it
+// words in Allow are always counted and skip the filters entirely
+type TokenFilterChain struct {
+	Allow   map[string]struct{}
+	Filters []TokenFilter
+}
+
+// NumericPolicy controls what happens to words containing digits
+type NumericPolicy string
+
+const (
+	NumericKeep         NumericPolicy = "keep"         // count every word
+	NumericDropLeading  NumericPolicy = "drop-leading" // drop words starting with a digit such as 2010 and 28th
+	NumericDropNumbers  NumericPolicy = "drop-numbers" // drop words made only of digits
+	NumericDropAnyDigit NumericPolicy = "drop-any"     // drop any word containing a digit
+)
+
+// TokenFilterConfig describes a filter chain so it can be loaded from a config file
+// zero values fall back to the defaults used by getWordCount
+type TokenFilterConfig struct {
+	MinLength int      `json:"min_length"` // in runes
+	MaxLength int      `json:"max_length"` // in runes, 0 for no limit
+	Numeric   string   `json:"numeric"`
+	Allow     []string `json:"allow"`
+	Deny      []string `json:"deny"`      // regular expressions matched against the whole word
+	Stopwords []string `json:"stopwords"` // stopword files, replacing the default list
+}
+
+// Keep reports whether the word should be counted
+func (c TokenFilterChain) Keep(word string) bool {
+	if _, ok := c.Allow[word]; ok {
+		return true
+	}
+	for _, filter := range c.Filters {
+		if !filter(word) {
+			return false
+		}
+	}
+	return true
+}
+
+// drops empty words
+func nonEmpty(word string) bool {
+	return word != ""
+}
+
+// minRuneLength drops words shorter than n runes, so multibyte words are measured by their characters
+func minRuneLength(n int) TokenFilter {
+	return func(word string) bool {
+		return utf8.RuneCountInString(word) >= n
+	}
+}
+
+// maxRuneLength drops words longer than n runes, such as hashes and run together markup
+func maxRuneLength(n int) TokenFilter {
+	return func(word string) bool {
+		return utf8.RuneCountInString(word) <= n
+	}
+}
+
+// numericFilter returns a filter applying the numeric policy
+func numericFilter(policy NumericPolicy) (TokenFilter, error) {
+	switch policy {
+	case NumericKeep:
+		return func(string) bool { return true }, nil
+	case NumericDropLeading:
+		return func(word string) bool {
+			first, _ := utf8.DecodeRuneInString(word)
+			return !unicode.IsDigit(first)
+		}, nil
+	case NumericDropNumbers:
+		return func(word string) bool {
+			return strings.IndexFunc(word, func(r rune) bool { return !unicode.IsDigit(r) }) != -1
+		}, nil
+	case NumericDropAnyDigit:
+		return func(word string) bool {
+			return strings.IndexFunc(word, unicode.IsDigit) == -1
+		}, nil
+	}
+	return nil, errors.New("unknown numeric policy: " + string(policy))
+}
+
+// denyPatterns drops words matching any of the patterns
+func denyPatterns(patterns []*regexp.Regexp) TokenFilter {
+	return func(word string) bool {
+		for _, pattern := range patterns {
+			if pattern.MatchString(word) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// stopwordFilter drops stopwords
+func stopwordFilter(stopwords map[string]struct{}) TokenFilter {
+	return func(word string) bool {
+		_, stop := stopwords[word]
+		return !stop
+	}
+}
+
+// defaultTokenFilterChain is the chain getWordCount has always used: words must not be empty or start
+// with a digit, must be at least minLength runes long and must not be a stopword
+func defaultTokenFilterChain(stopwords map[string]struct{}, minLength int) TokenFilterChain {
+	leading, _ := numericFilter(NumericDropLeading)
+	return TokenFilterChain{
+		Filters: []TokenFilter{nonEmpty, leading, minRuneLength(minLength), stopwordFilter(stopwords)},
+	}
+}
+
+// build turns the config into a filter chain
+// stopwords is used unless the config lists its own stopword files
+func (c TokenFilterConfig) build(stopwords map[string]struct{}) (TokenFilterChain, error) {
+	minLength := c.MinLength
+	if minLength == 0 {
+		minLength = defaultMinWordLength
+	}
+	if minLength < 0 || c.MaxLength < 0 {
+		return TokenFilterChain{}, errors.New("word lengths must not be negative")
+	}
+	if c.MaxLength != 0 && c.MaxLength < minLength {
+		return TokenFilterChain{}, errors.New("max_length must not be below min_length")
+	}
+
+	policy := NumericPolicy(c.Numeric)
+	if policy == "" {
+		policy = NumericDropLeading
+	}
+	numeric, err := numericFilter(policy)
+	if err != nil {
+		return TokenFilterChain{}, err
+	}
+
+	// load the configured stopword files in place of the default list
+	if len(c.Stopwords) > 0 {
+		stopwords = make(map[string]struct{})
+		for _, path := range c.Stopwords {
+			loaded, err := LoadStopwords(path)
+			if err != nil {
+				return TokenFilterChain{}, err
+			}
+			for word := range loaded {
+				stopwords[word] = struct{}{}
+			}
+		}
+	}
+
+	chain := TokenFilterChain{
+		Filters: []TokenFilter{nonEmpty, numeric, minRuneLength(minLength), stopwordFilter(stopwords)},
+	}
+	if c.MaxLength > 0 {
+		chain.Filters = append(chain.Filters, maxRuneLength(c.MaxLength))
+	}
+
+	if len(c.Deny) > 0 {
+		patterns := make([]*regexp.Regexp, 0, len(c.Deny))
+		for _, expr := range c.Deny {
+			pattern, err := regexp.Compile("^(?:" + expr + ")$")
+			if err != nil {
+				return TokenFilterChain{}, errors.New("invalid deny pattern " + expr + ": " + err.Error())
+			}
+			patterns = append(patterns, pattern)
+		}
+		chain.Filters = append(chain.Filters, denyPatterns(patterns))
+	}
+
+	if len(c.Allow) > 0 {
+		chain.Allow = make(map[string]struct{}, len(c.Allow))
+		for _, word := range c.Allow {
+			chain.Allow[strings.ToLower(word)] = struct{}{}
+		}
+	}
+
+	return chain, nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+/*
+This file tests for:
+- the default chain matches the rules getWordCount has always used
+- multibyte words are measured in runes
+- each numeric policy
+- allow-list words skip every filter
+- deny patterns, max length and custom filters
+- building a chain from config and rejecting bad config
+- the default splitter keeps multibyte words whole
+*/
+func TestTokenFilterChain(t *testing.T) {
+	stopwords := map[string]struct{}{"the": {}}
+
+	// Test the default chain
+	t.Run("DefaultChain", func(t *testing.T) {
+		chain := defaultTokenFilterChain(stopwords, defaultMinWordLength)
+		cases := map[string]bool{
+			"":        false,
+			"go":      false,
+			"the":     false,
+			"2010":    false,
+			"28th":    false,
+			"haskell": true,
+			"ghc":     true,
+		}
+		for word, expected := range cases {
+			if got := chain.Keep(word); got != expected {
+				t.Errorf("Expected Keep(%q) to be %v, got %v", word, expected, got)
+			}
+		}
+	})
+
+	// Test multibyte words are measured in runes, not bytes
+	t.Run("RuneLength", func(t *testing.T) {
+		chain := defaultTokenFilterChain(stopwords, 3)
+		// two runes but four bytes
+		if chain.Keep("éé") {
+			t.Error("Expected two rune word 'éé' to be dropped")
+		}
+		if !chain.Keep("été") {
+			t.Error("Expected three rune word 'été' to be kept")
+		}
+		if maxRuneLength(3)("naïve") {
+			t.Error("Expected five rune word to be over a max length of 3")
+		}
+	})
+
+	// Test each numeric policy
+	t.Run("NumericPolicies", func(t *testing.T) {
+		cases := map[NumericPolicy]map[string]bool{
+			NumericKeep:         {"2010": true, "28th": true, "h264": true, "word": true},
+			NumericDropLeading:  {"2010": false, "28th": false, "h264": true, "word": true},
+			NumericDropNumbers:  {"2010": false, "28th": true, "h264": true, "word": true},
+			NumericDropAnyDigit: {"2010": false, "28th": false, "h264": false, "word": true},
+		}
+		for policy, words := range cases {
+			filter, err := numericFilter(policy)
+			if err != nil {
+				t.Fatalf("Expected no error for %s, got: %v", policy, err)
+			}
+			for word, expected := range words {
+				if got := filter(word); got != expected {
+					t.Errorf("%s: expected %q kept to be %v, got %v", policy, word, expected, got)
+				}
+			}
+		}
+
+		if _, err := numericFilter("sometimes"); err == nil {
+			t.Error("Expected error for unknown policy, got nil")
+		}
+	})
+
+	// Test allow-list and custom filters
+	t.Run("AllowAndCustom", func(t *testing.T) {
+		chain := defaultTokenFilterChain(stopwords, 3)
+		chain.Allow = map[string]struct{}{"io": {}}
+		chain.Filters = append(chain.Filters, func(word string) bool { return word != "haskell" })
+
+		if !chain.Keep("io") {
+			t.Error("Expected allow-listed 'io' to be kept despite its length")
+		}
+		if chain.Keep("haskell") {
+			t.Error("Expected custom filter to drop 'haskell'")
+		}
+	})
+}
+
+func TestTokenFilterConfig(t *testing.T) {
+	// Test a full config
+	t.Run("Build", func(t *testing.T) {
+		config := TokenFilterConfig{
+			MinLength: 2,
+			MaxLength: 10,
+			Numeric:   "drop-numbers",
+			Allow:     []string{"C"},
+			Deny:      []string{`x+`, `.*ing`},
+		}
+		chain, err := config.build(map[string]struct{}{"the": {}})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		cases := map[string]bool{
+			"c":               true,
+			"go":              true,
+			"the":             false,
+			"28th":            true,
+			"2010":            false,
+			"xxx":             false,
+			"pixel":           true,
+			"parsing":         false,
+			"internationally": false,
+		}
+		for word, expected := range cases {
+			if got := chain.Keep(word); got != expected {
+				t.Errorf("Expected Keep(%q) to be %v, got %v", word, expected, got)
+			}
+		}
+	})
+
+	// Test stopword files replace the given stopwords
+	t.Run("StopwordFiles", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "stop.txt")
+		os.WriteFile(path, []byte("haskell\n"), 0644)
+
+		chain, err := TokenFilterConfig{Stopwords: []string{path}}.build(map[string]struct{}{"the": {}})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if chain.Keep("haskell") || !chain.Keep("the") {
+			t.Error("Expected only the stopwords from the file to be used")
+		}
+	})
+
+	// Test bad configs are rejected
+	t.Run("Invalid", func(t *testing.T) {
+		bad := []TokenFilterConfig{
+			{MinLength: -1},
+			{MinLength: 5, MaxLength: 3},
+			{Numeric: "sometimes"},
+			{Deny: []string{"("}},
+			{Stopwords: []string{"no_such_file.txt"}},
+		}
+		for _, config := range bad {
+			if _, err := config.build(nil); err == nil {
+				t.Errorf("Expected error for %+v, got nil", config)
+			}
+		}
+	})
+}
+
+func TestExtractKeywordsWithTokenFilters(t *testing.T) {
+	chain, err := TokenFilterConfig{Numeric: "keep", Allow: []string{"io"}}.build(map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	keywords, err := extractKeywords("IO IO IO 2010 2010 haskell", ExtractOptions{NumKeywords: 3, TokenFilters: &chain})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	terms := keywordTerms(keywords)
+	if len(terms) != 3 || terms[0] != "io" || terms[1] != "2010" {
+		t.Errorf("Expected [io 2010 haskell], got %v", terms)
+	}
+
+	// Test multibyte words reach the rune length check whole
+	keywords, err = extractKeywords("Über Straße, über die Straße. Öl", ExtractOptions{NumKeywords: 3, Stopwords: map[string]struct{}{}})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	terms = keywordTerms(keywords)
+	if len(terms) != 3 || terms[0] != "straße" || terms[1] != "über" || terms[2] != "die" {
+		t.Errorf("Expected [straße über die], got %v", terms)
+	}
+}
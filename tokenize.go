@@ -0,0 +1,35 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Token is a single lowercased word along with its byte offsets into the original content
+type Token struct {
+	Text  string
+	Start int
+	End   int
+}
+
+// tokenize splits content into words using wordSplitter, keeping track of where each word came from
+// empty words between adjacent separators are dropped
+func tokenize(content string, wordSplitter *regexp.Regexp) []Token {
+	var tokens []Token
+
+	start := 0
+	separators := wordSplitter.FindAllStringIndex(content, -1)
+	separators = append(separators, []int{len(content), len(content)})
+	for _, sep := range separators {
+		if sep[0] > start {
+			tokens = append(tokens, Token{
+				Text:  strings.ToLower(content[start:sep[0]]),
+				Start: start,
+				End:   sep[0],
+			})
+		}
+		start = sep[1]
+	}
+
+	return tokens
+}
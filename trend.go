@@ -0,0 +1,312 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TrendBucket is one period of time with how many documents and counted words fell in it
+type TrendBucket struct {
+	Start     time.Time `json:"start"`
+	Documents int       `json:"documents"`
+	Words     int       `json:"words"`
+}
+
+// TrendKeyword is a term used more or less often in the recent buckets than in the ones before them
+// Z is the burst z-score, positive for emerging terms and negative for declining ones
+// the rates are the term's share of all counted words, Counts holds its count in every bucket oldest first
+type TrendKeyword struct {
+	Term        string  `json:"term"`
+	Z           float64 `json:"z"`
+	RecentRate  float64 `json:"recent_rate"`
+	HistoryRate float64 `json:"history_rate"`
+	Counts      []int   `json:"counts"`
+}
+
+// TrendReport lists the buckets found and the keywords that rose or fell, strongest first
+type TrendReport struct {
+	Bucket    string         `json:"bucket"`
+	Buckets   []TrendBucket  `json:"buckets"`
+	Skipped   int            `json:"skipped"`
+	Emerging  []TrendKeyword `json:"emerging"`
+	Declining []TrendKeyword `json:"declining"`
+}
+
+// TrendOptions controls trend detection
+// Bucket is day, week, month or year and Recent is how many of the latest buckets are compared against the rest
+// terms counted fewer than MinCount times over every bucket are ignored, and a term must reach a z-score of
+// Threshold either way to be reported, Top limits each list with 0 keeping every term
+type TrendOptions struct {
+	Bucket    string
+	Recent    int
+	MinCount  int
+	Threshold float64
+	Top       int
+}
+
+// the layout used to label the start of each size of bucket
+var bucketLayouts = map[string]string{
+	"day":   "2006-01-02",
+	"week":  "2006-01-02",
+	"month": "2006-01",
+	"year":  "2006",
+}
+
+// returns the start of the bucket t falls in, weeks start on a Monday and all buckets are in UTC
+func bucketStart(t time.Time, bucket string) time.Time {
+	t = t.UTC()
+	switch bucket {
+	case "week":
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case "year":
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// the z-score of seeing count occurrences in words counted words when the term's rate before was historyCount
+// in historyWords, half an occurrence is added to the history so terms new to the recent buckets still score
+func burstZScore(count, words, historyCount, historyWords int) float64 {
+	p := (float64(historyCount) + 0.5) / (float64(historyWords) + 1)
+	expected := float64(words) * p
+	return (float64(count) - expected) / math.Sqrt(float64(words)*p*(1-p))
+}
+
+// detectTrends counts the words of every document with opts, groups the counts into time buckets and reports
+// the terms whose rate in the recent buckets differs most from their rate in the earlier ones
+// documents no words could be counted in are skipped, buckets with no documents are left out
+func detectTrends(docs []Document, opts ExtractOptions, trend TrendOptions) (TrendReport, error) {
+	if _, ok := bucketLayouts[trend.Bucket]; !ok {
+		return TrendReport{}, errors.New("unknown bucket " + trend.Bucket + ", expected day, week, month or year")
+	}
+	if trend.Recent < 1 {
+		return TrendReport{}, errors.New("the number of recent buckets must be at least 1")
+	}
+	if trend.Threshold < 0 {
+		return TrendReport{}, errors.New("threshold must not be negative")
+	}
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return TrendReport{}, err
+	}
+
+	report := TrendReport{Bucket: trend.Bucket}
+	buckets := make(map[time.Time]*TrendBucket)
+	bucketCounts := make(map[time.Time]TermCountIndex)
+	for _, doc := range docs {
+		tci, err := countTerms(doc.Text, opts)
+		if err != nil {
+			report.Skipped++
+			continue
+		}
+
+		start := bucketStart(doc.Time, trend.Bucket)
+		bucket, ok := buckets[start]
+		if !ok {
+			bucket = &TrendBucket{Start: start}
+			buckets[start] = bucket
+			bucketCounts[start] = make(TermCountIndex)
+		}
+		bucket.Documents++
+		for term, count := range tci {
+			bucketCounts[start][term] += count
+			bucket.Words += count
+		}
+	}
+
+	for _, bucket := range buckets {
+		report.Buckets = append(report.Buckets, *bucket)
+	}
+	sort.Slice(report.Buckets, func(i, j int) bool {
+		return report.Buckets[i].Start.Before(report.Buckets[j].Start)
+	})
+	if len(report.Buckets) <= trend.Recent {
+		return TrendReport{}, errors.New("need more than " + strconv.Itoa(trend.Recent) + " " + trend.Bucket +
+			" buckets to compare, found " + strconv.Itoa(len(report.Buckets)))
+	}
+
+	// every term's count in each bucket, oldest first
+	series := make(map[string][]int)
+	for i, bucket := range report.Buckets {
+		for term, count := range bucketCounts[bucket.Start] {
+			if _, ok := series[term]; !ok {
+				series[term] = make([]int, len(report.Buckets))
+			}
+			series[term][i] = count
+		}
+	}
+
+	split := len(report.Buckets) - trend.Recent
+	recentWords, historyWords := 0, 0
+	for i, bucket := range report.Buckets {
+		if i < split {
+			historyWords += bucket.Words
+		} else {
+			recentWords += bucket.Words
+		}
+	}
+
+	for term, counts := range series {
+		recent, history := 0, 0
+		for i, count := range counts {
+			if i < split {
+				history += count
+			} else {
+				recent += count
+			}
+		}
+		if recent+history < trend.MinCount {
+			continue
+		}
+
+		keyword := TrendKeyword{
+			Term:        term,
+			Z:           burstZScore(recent, recentWords, history, historyWords),
+			RecentRate:  float64(recent) / float64(recentWords),
+			HistoryRate: float64(history) / float64(historyWords),
+			Counts:      counts,
+		}
+		switch {
+		case keyword.Z >= trend.Threshold:
+			report.Emerging = append(report.Emerging, keyword)
+		case keyword.Z <= -trend.Threshold:
+			report.Declining = append(report.Declining, keyword)
+		}
+	}
+
+	sort.Slice(report.Emerging, func(i, j int) bool {
+		if report.Emerging[i].Z != report.Emerging[j].Z {
+			return report.Emerging[i].Z > report.Emerging[j].Z
+		}
+		return report.Emerging[i].Term < report.Emerging[j].Term
+	})
+	sort.Slice(report.Declining, func(i, j int) bool {
+		if report.Declining[i].Z != report.Declining[j].Z {
+			return report.Declining[i].Z < report.Declining[j].Z
+		}
+		return report.Declining[i].Term < report.Declining[j].Term
+	})
+	if trend.Top > 0 {
+		report.Emerging = report.Emerging[:min(trend.Top, len(report.Emerging))]
+		report.Declining = report.Declining[:min(trend.Top, len(report.Declining))]
+	}
+
+	return report, nil
+}
+
+// the columns written for a trend report by every format but json and plain
+var trendColumns = []string{"direction", "term", "z", "recent_rate", "history_rate", "counts"}
+
+// the values for one trend keyword in the same order as trendColumns
+func trendRow(direction string, keyword TrendKeyword) []string {
+	counts := make([]string, len(keyword.Counts))
+	for i, count := range keyword.Counts {
+		counts[i] = strconv.Itoa(count)
+	}
+	return []string{
+		direction,
+		keyword.Term,
+		strconv.FormatFloat(keyword.Z, 'f', 3, 64),
+		strconv.FormatFloat(keyword.RecentRate, 'f', 6, 64),
+		strconv.FormatFloat(keyword.HistoryRate, 'f', 6, 64),
+		strings.Join(counts, " "),
+	}
+}
+
+// writes a trend report in one of the formats accepted by newResultWriter
+// plain writes one term per line marked + for emerging and - for declining
+func writeTrendReport(w io.Writer, format string, report TrendReport) error {
+	writer, err := newResultWriter(format)
+	if err != nil {
+		return err
+	}
+
+	if report.Emerging == nil {
+		report.Emerging = []TrendKeyword{}
+	}
+	if report.Declining == nil {
+		report.Declining = []TrendKeyword{}
+	}
+
+	// the table lists the buckets above the terms
+	layout := bucketLayouts[report.Bucket]
+	labels := make([]string, len(report.Buckets))
+	for i, bucket := range report.Buckets {
+		labels[i] = bucket.Start.Format(layout) + " (" + strconv.Itoa(bucket.Documents) + ")"
+	}
+	records := Records{Columns: trendColumns, Value: report, Header: []string{"buckets: " + strings.Join(labels, ", ")}}
+	if report.Skipped > 0 {
+		records.Header = append(records.Header, "skipped: "+strconv.Itoa(report.Skipped))
+	}
+	records.Header = append(records.Header, "")
+
+	for _, keyword := range report.Emerging {
+		records.Rows = append(records.Rows, trendRow("emerging", keyword))
+		records.Lines = append(records.Lines, "+"+keyword.Term)
+	}
+	for _, keyword := range report.Declining {
+		records.Rows = append(records.Rows, trendRow("declining", keyword))
+		records.Lines = append(records.Lines, "-"+keyword.Term)
+	}
+	return writer.WriteRecords(w, records)
+}
+
+// runTrend is the trend command, it reports emerging and declining keywords over time-stamped documents
+func runTrend(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("trend", flag.ContinueOnError)
+	dataset := flags.String("dataset", "", "JSONL file with a timestamp field, or directory of .txt documents dated by modification time")
+	timeField := flags.String("time-field", "timestamp", "JSONL field holding each document's date or unix seconds")
+	configPath := flags.String("config", "", "JSON, YAML or TOML config file")
+	format := flags.String("format", "table", "output format: "+strings.Join(outputFormatNames(), ", "))
+	bucket := flags.String("bucket", "month", "size of each time bucket: day, week, month or year")
+	recent := flags.Int("recent", 1, "number of latest buckets compared against the earlier ones")
+	minCount := flags.Int("min-count", 3, "ignore terms counted fewer times across every bucket")
+	threshold := flags.Float64("threshold", 2, "z-score a term must reach either way to be reported")
+	top := flags.Int("top", 10, "number of emerging and of declining keywords to print, 0 for all")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *dataset == "" {
+		return errors.New("trend needs a -dataset")
+	}
+
+	config, err := loadConfigWithFlags(*configPath, flags, map[string]func(*Config){
+		"format": func(c *Config) { c.Format = *format },
+	})
+	if err != nil {
+		return err
+	}
+	if config.Format == "" {
+		config.Format = *format
+	}
+	opts, err := config.options()
+	if err != nil {
+		return err
+	}
+
+	docs, err := loadTimedDocuments(*dataset, *timeField)
+	if err != nil {
+		return err
+	}
+
+	report, err := detectTrends(docs, opts, TrendOptions{
+		Bucket:    *bucket,
+		Recent:    *recent,
+		MinCount:  *minCount,
+		Threshold: *threshold,
+		Top:       *top,
+	})
+	if err != nil {
+		return err
+	}
+	return writeTrendReport(stdout, config.Format, report)
+}
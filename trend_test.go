@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+/*
+This file tests for:
+- the start of each size of bucket
+- the burst z-score
+- emerging and declining keywords across buckets
+- rejecting bad options and too few buckets
+- the trend command reading a JSONL dataset
+*/
+func TestBucketStart(t *testing.T) {
+	// a Thursday afternoon
+	moment := time.Date(2021, 5, 6, 15, 30, 0, 0, time.UTC)
+	expected := map[string]time.Time{
+		"day":   time.Date(2021, 5, 6, 0, 0, 0, 0, time.UTC),
+		"week":  time.Date(2021, 5, 3, 0, 0, 0, 0, time.UTC),
+		"month": time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC),
+		"year":  time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for bucket, start := range expected {
+		if got := bucketStart(moment, bucket); !got.Equal(start) {
+			t.Errorf("Expected %s to start at %v, got %v", bucket, start, got)
+		}
+	}
+
+	// a Sunday belongs to the week starting the Monday before
+	if got := bucketStart(time.Date(2021, 5, 9, 0, 0, 0, 0, time.UTC), "week"); got.Day() != 3 {
+		t.Errorf("Expected Sunday 9th to be in the week of the 3rd, got %v", got)
+	}
+}
+
+func TestBurstZScore(t *testing.T) {
+	// used at the same rate as before scores close to zero
+	if z := burstZScore(10, 1000, 100, 10000); math.Abs(z) > 0.2 {
+		t.Errorf("Expected a z-score near 0, got %.3f", z)
+	}
+	if z := burstZScore(50, 1000, 100, 10000); z < 10 {
+		t.Errorf("Expected a large positive z-score, got %.3f", z)
+	}
+	if z := burstZScore(0, 1000, 1000, 10000); z > -9 {
+		t.Errorf("Expected a large negative z-score, got %.3f", z)
+	}
+}
+
+func TestDetectTrends(t *testing.T) {
+	month := func(m time.Month) time.Time { return time.Date(2021, m, 10, 0, 0, 0, 0, time.UTC) }
+	docs := []Document{
+		{ID: "1", Time: month(1), Text: "python python python python java java code code code code"},
+		{ID: "2", Time: month(2), Text: "python python python python java java code code code code"},
+		{ID: "3", Time: month(3), Text: "rust rust rust rust java java code code code code"},
+		{ID: "4", Time: month(3), Text: "a an"},
+	}
+	opts := ExtractOptions{Stopwords: map[string]struct{}{}}
+
+	report, err := detectTrends(docs, opts, TrendOptions{Bucket: "month", Recent: 1, MinCount: 3, Threshold: 2})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(report.Buckets) != 3 || report.Skipped != 1 {
+		t.Fatalf("Expected 3 buckets and 1 skipped document, got %d and %d", len(report.Buckets), report.Skipped)
+	}
+	if len(report.Emerging) != 1 || report.Emerging[0].Term != "rust" {
+		t.Errorf("Expected only rust to be emerging, got %+v", report.Emerging)
+	}
+	if len(report.Declining) != 1 || report.Declining[0].Term != "python" {
+		t.Errorf("Expected only python to be declining, got %+v", report.Declining)
+	}
+	if counts := report.Emerging[0].Counts; len(counts) != 3 || counts[2] != 4 || counts[0] != 0 {
+		t.Errorf("Expected rust counts [0 0 4], got %v", counts)
+	}
+
+	// Test bad options are rejected
+	bad := []TrendOptions{
+		{Bucket: "hour", Recent: 1},
+		{Bucket: "month", Recent: 0},
+		{Bucket: "month", Recent: 1, Threshold: -1},
+		{Bucket: "year", Recent: 1},
+	}
+	for _, trend := range bad {
+		if _, err := detectTrends(docs, opts, trend); err == nil {
+			t.Errorf("Expected error for %+v, got nil", trend)
+		}
+	}
+}
+
+func TestRunTrend(t *testing.T) {
+	var lines []string
+	for i, text := range []string{
+		"Haskell monads are lazy. Haskell monads compose.",
+		"Haskell monads are pure. Haskell monads again.",
+		"Rust borrow checker. Rust ownership. Rust lifetimes and Rust traits.",
+	} {
+		line, _ := json.Marshal(map[string]any{"text": text, "published": "2021-0" + string(rune('1'+i)) + "-15"})
+		lines = append(lines, string(line))
+	}
+	path := filepath.Join(t.TempDir(), "articles.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := runTrend([]string{"-dataset", path, "-time-field", "published", "-format", "plain"}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(out.String(), "+rust\n") || !strings.Contains(out.String(), "-haskell\n") {
+		t.Errorf("Expected rust emerging and haskell declining, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := runTrend([]string{"-dataset", path, "-time-field", "published"}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "buckets: 2021-01 (1), 2021-02 (1), 2021-03 (1)") {
+		t.Errorf("Expected the buckets to be listed, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// the namespaces SKOS vocabularies are written in
+const (
+	skosNamespace = "http://www.w3.org/2004/02/skos/core#"
+	rdfNamespace  = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+)
+
+// Concept is an entry of a controlled vocabulary, PrefLabel is the term written out for it
+// and AltLabels are synonyms, abbreviations and other spellings that mean the same thing
+type Concept struct {
+	ID        string
+	PrefLabel string
+	AltLabels []string
+}
+
+// Vocabulary maps labels onto the concepts of a taxonomy
+// labels are matched ignoring case and spacing, then preferred labels by their plural, -ed and -ing free forms so "compilers" finds "compiler"
+type Vocabulary struct {
+	Concepts []Concept
+	labels   map[string]int // normalised label to concept index
+	stems    map[string]int // preferred label without inflections to concept index
+}
+
+// VocabularyOptions maps extracted terms onto a vocabulary
+// terms that are not in the vocabulary are kept as they are unless Restrict is set
+type VocabularyOptions struct {
+	Vocabulary *Vocabulary
+	Restrict   bool
+}
+
+// VocabularyConfig names a vocabulary file so it can be set in a config file
+type VocabularyConfig struct {
+	Path     string `json:"path"`     // SKOS RDF/XML (.rdf, .xml) or CSV file, empty for none
+	Language string `json:"language"` // the SKOS prefLabel language written out, empty for en
+	Restrict bool   `json:"restrict"` // drop terms that are not in the vocabulary
+}
+
+// lowercases a term or label and collapses its spacing
+func normaliseTerm(term string) string {
+	return strings.Join(strings.Fields(strings.ToLower(term)), " ")
+}
+
+// newVocabulary indexes the labels of concepts
+// preferred labels are indexed before alternative ones, so when two concepts share a label the concept
+// that prefers it wins, and otherwise the first concept does
+func newVocabulary(concepts []Concept) (*Vocabulary, error) {
+	vocabulary := &Vocabulary{
+		labels: make(map[string]int),
+		stems:  make(map[string]int),
+	}
+	for _, concept := range concepts {
+		concept.PrefLabel = strings.TrimSpace(concept.PrefLabel)
+		if concept.PrefLabel == "" {
+			return nil, errors.New("concept " + concept.ID + " has no preferred label")
+		}
+		if concept.ID == "" {
+			concept.ID = concept.PrefLabel
+		}
+		vocabulary.Concepts = append(vocabulary.Concepts, concept)
+	}
+	if len(vocabulary.Concepts) == 0 {
+		return nil, errors.New("no concepts found")
+	}
+
+	for i, concept := range vocabulary.Concepts {
+		vocabulary.addLabel(concept.PrefLabel, i, true)
+	}
+	for i, concept := range vocabulary.Concepts {
+		for _, label := range concept.AltLabels {
+			vocabulary.addLabel(label, i, false)
+		}
+	}
+	return vocabulary, nil
+}
+
+// indexes a label for a concept unless another concept already has it
+// only preferred labels are indexed by stem, as alternative labels such as "functional" would otherwise
+// catch every word sharing their stem
+func (v *Vocabulary) addLabel(label string, concept int, preferred bool) {
+	label = normaliseTerm(label)
+	if label == "" {
+		return
+	}
+	if _, ok := v.labels[label]; !ok {
+		v.labels[label] = concept
+	}
+	if _, ok := v.stems[inflectionStem(label)]; preferred && !ok {
+		v.stems[inflectionStem(label)] = concept
+	}
+}
+
+// Lookup returns the concept a term is a label of
+func (v *Vocabulary) Lookup(term string) (Concept, bool) {
+	label := normaliseTerm(term)
+	if i, ok := v.labels[label]; ok {
+		return v.Concepts[i], true
+	}
+	if i, ok := v.stems[inflectionStem(label)]; ok {
+		return v.Concepts[i], true
+	}
+	return Concept{}, false
+}
+
+// Map replaces each keyword that is a label of a concept with the concept's preferred label
+// keywords landing on the same concept are merged, adding their scores and counts, and the result is
+// ranked again in descending order of score
+func (opts VocabularyOptions) Map(keywords []Keyword) []Keyword {
+	mapped := make([]Keyword, 0, len(keywords))
+	positions := make(map[string]int, len(keywords))
+	for _, keyword := range keywords {
+		if concept, ok := opts.Vocabulary.Lookup(keyword.Term); ok {
+			keyword.Term = concept.PrefLabel
+			keyword.Concept = concept.ID
+		} else if opts.Restrict {
+			continue
+		}
+
+		if i, ok := positions[keyword.Term]; ok {
+			mapped[i].Score += keyword.Score
+			mapped[i].Count += keyword.Count
+			mapped[i].Snippets = append(mapped[i].Snippets, keyword.Snippets...)
+			continue
+		}
+		positions[keyword.Term] = len(mapped)
+		mapped = append(mapped, keyword)
+	}
+
+	sort.SliceStable(mapped, func(i, j int) bool {
+		if mapped[i].Score != mapped[j].Score {
+			return mapped[i].Score > mapped[j].Score
+		}
+		return mapped[i].Term < mapped[j].Term
+	})
+	return mapped
+}
+
+// loadVocabulary reads a SKOS RDF/XML or CSV vocabulary, picking the format from the file extension
+// language picks which SKOS prefLabel is written out, labels in other languages are kept as alternatives
+func loadVocabulary(path, language string) (*Vocabulary, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.New("Error opening file, check file path: " + err.Error())
+	}
+	defer file.Close()
+
+	var concepts []Concept
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".rdf", ".xml":
+		concepts, err = parseSKOS(file, language)
+	case ".csv":
+		concepts, err = parseVocabularyCSV(file)
+	default:
+		return nil, errors.New(path + ": unknown vocabulary format, expected SKOS .rdf or .xml, or .csv")
+	}
+	if err != nil {
+		return nil, errors.New(path + ": " + err.Error())
+	}
+	vocabulary, err := newVocabulary(concepts)
+	if err != nil {
+		return nil, errors.New(path + ": " + err.Error())
+	}
+	return vocabulary, nil
+}
+
+// a SKOS label with its language tag
+type skosLabel struct {
+	Lang string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Text string `xml:",chardata"`
+}
+
+// a skos:Concept element, or an rdf:Description typed as one
+type skosConcept struct {
+	About string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Types []struct {
+		Resource string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# resource,attr"`
+	} `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# type"`
+	PrefLabels   []skosLabel `xml:"http://www.w3.org/2004/02/skos/core# prefLabel"`
+	AltLabels    []skosLabel `xml:"http://www.w3.org/2004/02/skos/core# altLabel"`
+	HiddenLabels []skosLabel `xml:"http://www.w3.org/2004/02/skos/core# hiddenLabel"`
+}
+
+// reads the concepts of a SKOS vocabulary written as RDF/XML
+// the prefLabel in language is preferred, then one with no language, then the first
+func parseSKOS(r io.Reader, language string) ([]Concept, error) {
+	if language == "" {
+		language = "en"
+	}
+
+	var concepts []Concept
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		isConcept := start.Name.Space == skosNamespace && start.Name.Local == "Concept"
+		if !isConcept && !(start.Name.Space == rdfNamespace && start.Name.Local == "Description") {
+			continue
+		}
+
+		var element skosConcept
+		if err := decoder.DecodeElement(&element, &start); err != nil {
+			return nil, err
+		}
+		for _, t := range element.Types {
+			isConcept = isConcept || t.Resource == skosNamespace+"Concept"
+		}
+		if !isConcept || len(element.PrefLabels) == 0 {
+			continue
+		}
+
+		preferred := 0
+		for i, label := range element.PrefLabels {
+			if strings.EqualFold(label.Lang, language) {
+				preferred = i
+				break
+			}
+			if label.Lang == "" && element.PrefLabels[preferred].Lang != "" {
+				preferred = i
+			}
+		}
+
+		concept := Concept{ID: element.About, PrefLabel: element.PrefLabels[preferred].Text}
+		for i, label := range element.PrefLabels {
+			if i != preferred {
+				concept.AltLabels = append(concept.AltLabels, label.Text)
+			}
+		}
+		for _, label := range append(element.AltLabels, element.HiddenLabels...) {
+			concept.AltLabels = append(concept.AltLabels, label.Text)
+		}
+		concepts = append(concepts, concept)
+	}
+	return concepts, nil
+}
+
+// reads a CSV vocabulary with a header row naming its columns
+// pref_label is required, id is optional and alt_labels holds alternative labels separated by |
+// other columns are ignored
+func parseVocabularyCSV(r io.Reader) ([]Concept, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("missing header row")
+	}
+
+	columns := map[string]int{"id": -1, "pref_label": -1, "alt_labels": -1}
+	for i, name := range rows[0] {
+		if _, ok := columns[strings.TrimSpace(name)]; ok {
+			columns[strings.TrimSpace(name)] = i
+		}
+	}
+	if columns["pref_label"] == -1 {
+		return nil, errors.New("header has no pref_label column")
+	}
+	cell := func(row []string, column string) string {
+		if i := columns[column]; i >= 0 && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	concepts := make([]Concept, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		concept := Concept{ID: cell(row, "id"), PrefLabel: cell(row, "pref_label")}
+		for _, label := range strings.Split(cell(row, "alt_labels"), "|") {
+			if label = strings.TrimSpace(label); label != "" {
+				concept.AltLabels = append(concept.AltLabels, label)
+			}
+		}
+		concepts = append(concepts, concept)
+	}
+	return concepts, nil
+}
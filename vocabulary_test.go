@@ -0,0 +1,180 @@
+package main
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+/*
+This file tests for:
+- reading SKOS RDF/XML concepts, including rdf:Description and language tags
+- reading CSV vocabularies
+- looking up preferred, alternative and stemmed labels
+- stems of alternative labels and shorter words do not match
+- mapping keywords onto concepts, merging synonyms and restricting to the vocabulary
+- setting a vocabulary in a config file
+*/
+
+const testSKOS = `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns:skos="http://www.w3.org/2004/02/skos/core#">
+  <skos:ConceptScheme rdf:about="http://example.org/languages"/>
+  <skos:Concept rdf:about="http://example.org/haskell">
+    <skos:prefLabel xml:lang="fr">Haskell (langage)</skos:prefLabel>
+    <skos:prefLabel xml:lang="en">Haskell</skos:prefLabel>
+    <skos:altLabel>Haskell 2010</skos:altLabel>
+  </skos:Concept>
+  <rdf:Description rdf:about="http://example.org/ghc">
+    <rdf:type rdf:resource="http://www.w3.org/2004/02/skos/core#Concept"/>
+    <skos:prefLabel>Glasgow Haskell Compiler</skos:prefLabel>
+    <skos:altLabel>GHC</skos:altLabel>
+    <skos:hiddenLabel>ghci</skos:hiddenLabel>
+  </rdf:Description>
+  <rdf:Description rdf:about="http://example.org/not-a-concept">
+    <skos:prefLabel>Ignored</skos:prefLabel>
+  </rdf:Description>
+</rdf:RDF>`
+
+func TestParseSKOS(t *testing.T) {
+	concepts, err := parseSKOS(strings.NewReader(testSKOS), "")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := []Concept{
+		{ID: "http://example.org/haskell", PrefLabel: "Haskell", AltLabels: []string{"Haskell (langage)", "Haskell 2010"}},
+		{ID: "http://example.org/ghc", PrefLabel: "Glasgow Haskell Compiler", AltLabels: []string{"GHC", "ghci"}},
+	}
+	if !reflect.DeepEqual(concepts, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, concepts)
+	}
+
+	french, _ := parseSKOS(strings.NewReader(testSKOS), "fr")
+	if french[0].PrefLabel != "Haskell (langage)" {
+		t.Errorf("Expected the French label, got %s", french[0].PrefLabel)
+	}
+
+	if _, err := parseSKOS(strings.NewReader("<rdf:RDF"), ""); err == nil {
+		t.Error("Expected error for broken XML, got nil")
+	}
+}
+
+func TestParseVocabularyCSV(t *testing.T) {
+	concepts, err := parseVocabularyCSV(strings.NewReader("notes,pref_label,alt_labels\nx,Monad,monads | monadic\ny,Functor\n"))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := []Concept{{PrefLabel: "Monad", AltLabels: []string{"monads", "monadic"}}, {PrefLabel: "Functor"}}
+	if !reflect.DeepEqual(concepts, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, concepts)
+	}
+
+	if _, err := parseVocabularyCSV(strings.NewReader("id,label\n1,Monad\n")); err == nil {
+		t.Error("Expected error without a pref_label column, got nil")
+	}
+}
+
+func TestVocabularyMap(t *testing.T) {
+	concepts, _ := parseSKOS(strings.NewReader(testSKOS), "")
+	vocabulary, err := newVocabulary(append(concepts, Concept{PrefLabel: "compiler"}))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	// Test looking up labels
+	t.Run("Lookup", func(t *testing.T) {
+		for term, expected := range map[string]string{
+			"ghc":                       "Glasgow Haskell Compiler",
+			"glasgow  haskell compiler": "Glasgow Haskell Compiler",
+			"GHCi":                      "Glasgow Haskell Compiler",
+			"compilers":                 "compiler",
+			"haskell":                   "Haskell",
+		} {
+			concept, ok := vocabulary.Lookup(term)
+			if !ok || concept.PrefLabel != expected {
+				t.Errorf("%s: expected %s, got %+v", term, expected, concept)
+			}
+		}
+		if _, ok := vocabulary.Lookup("rust"); ok {
+			t.Error("Expected rust not to be found")
+		}
+	})
+
+	// Test only whole preferred labels match by stem
+	t.Run("StemMisses", func(t *testing.T) {
+		functional, err := newVocabulary([]Concept{
+			{PrefLabel: "functional programming", AltLabels: []string{"functional"}},
+			{PrefLabel: "Functional Language"},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		for _, term := range []string{"function", "functions", "functionally", "function programming", "function language"} {
+			if concept, ok := functional.Lookup(term); ok {
+				t.Errorf("%s: expected no concept, got %+v", term, concept)
+			}
+		}
+		if concept, ok := functional.Lookup("functional languages"); !ok || concept.PrefLabel != "Functional Language" {
+			t.Errorf("functional languages: expected Functional Language, got %+v", concept)
+		}
+	})
+
+	keywords := []Keyword{
+		{Term: "haskell", Score: 0.3, Count: 3},
+		{Term: "ghc", Score: 0.2, Count: 2},
+		{Term: "laziness", Score: 0.15, Count: 1},
+		{Term: "glasgow haskell compiler", Score: 0.15, Count: 1},
+	}
+
+	// Test synonyms are merged onto one concept
+	t.Run("Merge", func(t *testing.T) {
+		mapped := VocabularyOptions{Vocabulary: vocabulary}.Map(keywords)
+		expected := []Keyword{
+			{Term: "Glasgow Haskell Compiler", Score: 0.35, Count: 3, Concept: "http://example.org/ghc"},
+			{Term: "Haskell", Score: 0.3, Count: 3, Concept: "http://example.org/haskell"},
+			{Term: "laziness", Score: 0.15, Count: 1},
+		}
+		if !reflect.DeepEqual(mapped, expected) {
+			t.Errorf("Expected %+v, got %+v", expected, mapped)
+		}
+	})
+
+	// Test restricting to the vocabulary
+	t.Run("Restrict", func(t *testing.T) {
+		mapped := VocabularyOptions{Vocabulary: vocabulary, Restrict: true}.Map(keywords)
+		if len(mapped) != 2 {
+			t.Errorf("Expected 2 keywords, got %+v", mapped)
+		}
+	})
+
+	if _, err := newVocabulary(nil); err == nil {
+		t.Error("Expected error for an empty vocabulary, got nil")
+	}
+}
+
+func TestVocabularyConfig(t *testing.T) {
+	vocabulary := writeConfigFile(t, "vocab.csv", "id,pref_label,alt_labels\nfp,functional programming,functional\n")
+	stopwords := writeConfigFile(t, "stopwords.txt", "the\n")
+	path := writeConfigFile(t, "config.json", `{"filters": {"stopwords": [`+strconv.Quote(stopwords)+`]}, "vocabulary": {"path": `+strconv.Quote(vocabulary)+`, "restrict": true}}`)
+
+	config, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	opts, err := config.options()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	keywords, err := extractKeywords("Haskell is functional. Haskell is lazy.", opts)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(keywords) != 1 || keywords[0].Term != "functional programming" || keywords[0].Concept != "fp" {
+		t.Errorf("Expected only functional programming, got %+v", keywords)
+	}
+
+	bad := writeConfigFile(t, "bad.json", `{"vocabulary": {"restrict": true}}`)
+	if _, err := loadConfig(bad); err == nil || !strings.Contains(err.Error(), "vocabulary.restrict") {
+		t.Errorf("Expected a vocabulary.restrict error, got: %v", err)
+	}
+}
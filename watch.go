@@ -0,0 +1,253 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DirectoryWatcher polls a directory tree for files that were created, changed or deleted
+// a file is only reported once it has gone Debounce without changing, so a burst of writes is one event
+// files and directories whose names start with a dot are ignored, as are sidecars
+type DirectoryWatcher struct {
+	Dir        string
+	Extensions []string // only files ending in one of these are watched
+	Debounce   time.Duration
+
+	files   map[string]watchedFile
+	pending map[string]time.Time // files changed since they were last reported, with when they last changed
+}
+
+// WatchEvent is a file that changed, or was deleted when Removed is set
+type WatchEvent struct {
+	Path    string
+	Removed bool
+}
+
+// what is compared between polls to tell whether a file changed
+type watchedFile struct {
+	ModTime time.Time
+	Size    int64
+}
+
+// returns a watcher for the files under dir ending in one of extensions
+func newDirectoryWatcher(dir string, extensions []string, debounce time.Duration) *DirectoryWatcher {
+	return &DirectoryWatcher{
+		Dir:        dir,
+		Extensions: extensions,
+		Debounce:   debounce,
+		files:      make(map[string]watchedFile),
+		pending:    make(map[string]time.Time),
+	}
+}
+
+// Start takes the first look at the directory, queueing the files whose sidecar is missing or older than
+// the file and the sidecars whose file is gone, so changes made while nothing was watching are caught up on
+// queued files are reported by the next Poll without waiting for Debounce
+func (w *DirectoryWatcher) Start() error {
+	files, sidecars, err := w.scan()
+	if err != nil {
+		return err
+	}
+	w.files = files
+	for path, file := range files {
+		if modTime, ok := sidecars[path]; !ok || modTime.Before(file.ModTime) {
+			w.pending[path] = time.Time{}
+		}
+	}
+	for path := range sidecars {
+		if _, ok := files[path]; !ok {
+			w.pending[path] = time.Time{}
+		}
+	}
+	return nil
+}
+
+// Poll looks at the directory again and returns the files that have settled since changing, sorted by path
+func (w *DirectoryWatcher) Poll(now time.Time) ([]WatchEvent, error) {
+	files, _, err := w.scan()
+	if err != nil {
+		return nil, err
+	}
+	for path, file := range files {
+		if old, ok := w.files[path]; !ok || old != file {
+			w.pending[path] = now
+		}
+	}
+	for path := range w.files {
+		if _, ok := files[path]; !ok {
+			w.pending[path] = now
+		}
+	}
+	w.files = files
+
+	var events []WatchEvent
+	for path, changed := range w.pending {
+		if now.Sub(changed) < w.Debounce {
+			continue
+		}
+		// a file deleted and written again within the debounce, as some editors save, is a change
+		_, exists := files[path]
+		events = append(events, WatchEvent{Path: path, Removed: !exists})
+		delete(w.pending, path)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Path < events[j].Path })
+	return events, nil
+}
+
+// walks the directory for watched files, along with the modification times of sidecars keyed by their file
+func (w *DirectoryWatcher) scan() (map[string]watchedFile, map[string]time.Time, error) {
+	files := make(map[string]watchedFile)
+	sidecars := make(map[string]time.Time)
+	err := filepath.WalkDir(w.Dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// a file deleted part way through the walk is picked up by the next poll
+			if errors.Is(err, os.ErrNotExist) && path != w.Dir {
+				return nil
+			}
+			return err
+		}
+		if path != w.Dir && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		if strings.HasSuffix(path, sidecarSuffix) {
+			sidecars[strings.TrimSuffix(path, sidecarSuffix)] = info.ModTime()
+			return nil
+		}
+		if w.watches(path) {
+			files[path] = watchedFile{ModTime: info.ModTime(), Size: info.Size()}
+		}
+		return nil
+	})
+	return files, sidecars, err
+}
+
+// reports whether a path ends in one of the watched extensions
+func (w *DirectoryWatcher) watches(path string) bool {
+	for _, extension := range w.Extensions {
+		if strings.HasSuffix(path, extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// re-extracts a changed file into its sidecar or front matter, or removes the sidecar of a deleted one
+// returns a line saying what was done, or nothing when the file already had its keywords
+func applyWatchEvent(event WatchEvent, output string, cache *KeywordCache, config Config) (string, error) {
+	if event.Removed {
+		if output != sidecarOutput {
+			return "", nil
+		}
+		return "removed " + sidecarPath(event.Path), removeSidecar(event.Path)
+	}
+	changed, err := updateKeywordMetadata(event.Path, output, func(content string) ([]Keyword, error) {
+		return cache.Extract(content, config)
+	})
+	if err != nil || !changed {
+		return "", err
+	}
+	if output == sidecarOutput {
+		return "updated " + sidecarPath(event.Path), nil
+	}
+	return "updated " + event.Path, nil
+}
+
+// runWatch is the watch command, it keeps a sidecar of keywords beside every matching file under a directory,
+// or keywords in their front matter, until interrupted, or only catches up once with -once
+func runWatch(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("watch", flag.ContinueOnError)
+	dir := flags.String("dir", "", "directory to watch, including subdirectories")
+	extensions := flags.String("ext", ".md,.txt", "comma separated extensions of the files to extract keywords from")
+	interval := flags.Duration("interval", time.Second, "how often to look for changes")
+	debounce := flags.Duration("debounce", 500*time.Millisecond, "how long a file must go unchanged before it is extracted")
+	once := flags.Bool("once", false, "update out of date sidecars then exit instead of watching")
+	configPath := flags.String("config", "", "JSON, YAML or TOML config file")
+	numKeywords := flags.Int("n", 10, "number of keywords written for each file")
+	output := flags.String("write", sidecarOutput, "where keywords are written: "+sidecarOutput+" or "+frontMatterOutput)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *dir == "" {
+		return errors.New("watch needs a -dir")
+	}
+	if *interval <= 0 || *debounce < 0 {
+		return errors.New("interval must be positive and debounce must not be negative")
+	}
+	if *output != sidecarOutput && *output != frontMatterOutput {
+		return errors.New("unknown output " + *output + ", expected " + sidecarOutput + " or " + frontMatterOutput)
+	}
+
+	config, err := loadConfigWithFlags(*configPath, flags, map[string]func(*Config){
+		"n": func(c *Config) { c.NumKeywords = *numKeywords },
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := config.options(); err != nil {
+		return err
+	}
+	// saving a file without changing it, or changing it back, reuses the earlier result
+	cache, err := newKeywordCache(1000, "")
+	if err != nil {
+		return err
+	}
+
+	watcher := newDirectoryWatcher(*dir, parseNameList(*extensions), *debounce)
+	if err := watcher.Start(); err != nil {
+		return err
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	defer signal.Stop(stop)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	for {
+		events, err := watcher.Poll(time.Now())
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			// writing front matter changes the file, which is seen as a change and found to need nothing more
+			done, err := applyWatchEvent(event, *output, cache, config)
+			if err != nil {
+				// one unreadable file should not stop the others being watched
+				fmt.Fprintln(os.Stderr, "Error:", event.Path+":", err)
+				continue
+			}
+			if done != "" {
+				fmt.Fprintln(stdout, done)
+			}
+		}
+		if *once {
+			return nil
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
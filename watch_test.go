@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+/*
+This file tests for:
+- queueing files with missing or stale sidecars and orphaned sidecars on start
+- waiting for a burst of changes to settle before reporting a file
+- reporting deleted files
+- ignoring hidden files, sidecars and other extensions
+- the watch command writing and removing sidecars once
+- the watch command writing front matter without rewriting it on the next run
+*/
+
+// writes a file and sets its modification time, as polls can be closer together than the file system's clock
+func writeWatchedFile(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDirectoryWatcher(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Now().Add(-time.Hour)
+	fresh := filepath.Join(dir, "fresh.md")
+	stale := filepath.Join(dir, "stale.md")
+	os.Mkdir(filepath.Join(dir, "sub"), 0755)
+	missing := filepath.Join(dir, "sub", "missing.txt")
+
+	writeWatchedFile(t, fresh, "fresh", base)
+	writeWatchedFile(t, sidecarPath(fresh), "[]", base.Add(time.Minute))
+	writeWatchedFile(t, stale, "stale", base.Add(time.Minute))
+	writeWatchedFile(t, sidecarPath(stale), "[]", base)
+	writeWatchedFile(t, missing, "missing", base)
+	writeWatchedFile(t, sidecarPath(filepath.Join(dir, "gone.md")), "[]", base)
+	writeWatchedFile(t, filepath.Join(dir, "image.png"), "png", base)
+	os.Mkdir(filepath.Join(dir, ".git"), 0755)
+	writeWatchedFile(t, filepath.Join(dir, ".git", "notes.md"), "hidden", base)
+	writeWatchedFile(t, filepath.Join(dir, ".draft.md"), "hidden", base)
+
+	watcher := newDirectoryWatcher(dir, []string{".md", ".txt"}, time.Second)
+	if err := watcher.Start(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	now := time.Now()
+	events, err := watcher.Poll(now)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := []WatchEvent{
+		{Path: filepath.Join(dir, "gone.md"), Removed: true},
+		{Path: stale},
+		{Path: missing},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Expected %v on start, got %v", expected, events)
+	}
+
+	// Test a burst of writes is reported once it settles
+	t.Run("Debounce", func(t *testing.T) {
+		writeWatchedFile(t, fresh, "first", base.Add(2*time.Minute))
+		if events, _ := watcher.Poll(now); len(events) != 0 {
+			t.Errorf("Expected nothing before the debounce, got %v", events)
+		}
+		writeWatchedFile(t, fresh, "second write", base.Add(3*time.Minute))
+		if events, _ := watcher.Poll(now.Add(800 * time.Millisecond)); len(events) != 0 {
+			t.Errorf("Expected the second write to restart the debounce, got %v", events)
+		}
+		events, _ := watcher.Poll(now.Add(2 * time.Second))
+		if !reflect.DeepEqual(events, []WatchEvent{{Path: fresh}}) {
+			t.Errorf("Expected one change to fresh.md, got %v", events)
+		}
+		if events, _ := watcher.Poll(now.Add(3 * time.Second)); len(events) != 0 {
+			t.Errorf("Expected nothing once reported, got %v", events)
+		}
+	})
+
+	// Test deleting a file
+	t.Run("Delete", func(t *testing.T) {
+		os.Remove(missing)
+		watcher.Poll(now.Add(4 * time.Second))
+		events, _ := watcher.Poll(now.Add(5 * time.Second))
+		if !reflect.DeepEqual(events, []WatchEvent{{Path: missing, Removed: true}}) {
+			t.Errorf("Expected missing.txt to be removed, got %v", events)
+		}
+	})
+}
+
+func TestRunWatch(t *testing.T) {
+	dir := t.TempDir()
+	note := filepath.Join(dir, "note.txt")
+	os.WriteFile(note, []byte("Haskell monads. Haskell laziness. Haskell monads."), 0644)
+	os.WriteFile(sidecarPath(filepath.Join(dir, "old.txt")), []byte("[]"), 0644)
+	stopwords := writeConfigFile(t, "stopwords.txt", "the\n")
+	config := writeConfigFile(t, "config.json", `{"filters": {"stopwords": ["`+filepath.ToSlash(stopwords)+`"]}}`)
+
+	var out bytes.Buffer
+	if err := runWatch([]string{"-dir", dir, "-once", "-n", "2", "-config", config}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := "updated " + sidecarPath(note) + "\nremoved " + sidecarPath(filepath.Join(dir, "old.txt")) + "\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	data, err := os.ReadFile(sidecarPath(note))
+	if err != nil {
+		t.Fatalf("Expected a sidecar, got: %v", err)
+	}
+	if !bytes.Contains(data, []byte(`"term": "haskell"`)) {
+		t.Errorf("Expected haskell in the sidecar, got:\n%s", data)
+	}
+
+	// a second run has nothing to do
+	out.Reset()
+	if err := runWatch([]string{"-dir", dir, "-once"}, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected no output, got %q", out.String())
+	}
+
+	if err := runWatch([]string{"-once"}, &out); err == nil {
+		t.Error("Expected error without -dir, got nil")
+	}
+}
+
+func TestRunWatchFrontMatter(t *testing.T) {
+	dir := t.TempDir()
+	note := filepath.Join(dir, "note.md")
+	os.WriteFile(note, []byte("---\ntitle: Note\n---\nHaskell monads. Haskell laziness. Haskell monads.\n"), 0644)
+	stopwords := writeConfigFile(t, "stopwords.txt", "the\n")
+	config := writeConfigFile(t, "config.json", `{"filters": {"stopwords": ["`+filepath.ToSlash(stopwords)+`"]}}`)
+	args := []string{"-dir", dir, "-once", "-n", "1", "-write", frontMatterOutput, "-config", config}
+
+	var out bytes.Buffer
+	if err := runWatch(args, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if out.String() != "updated "+note+"\n" {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+	data, _ := os.ReadFile(note)
+	if !bytes.HasPrefix(data, []byte("---\ntitle: Note\nkeywords:\n  - haskell\n---\n")) {
+		t.Errorf("Unexpected file:\n%s", data)
+	}
+
+	out.Reset()
+	if err := runWatch(args, &out); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing to change, got %q", out.String())
+	}
+}
@@ -5,31 +5,37 @@ import (
 	"regexp"
 	"sort"
 	"strings"
-	"unicode"
 )
 
+// words must be at least this many runes long to be counted unless configured otherwise
+const defaultMinWordLength = 3
+
+// returned when content has no words left to count, so callers can tell an empty document from a failure
+var errNoValidWords = errors.New("no valid words found in content")
+
 type TermCountIndex map[string]int
 type TermFrequencyIndex map[string]float64
 
 // Returns a map of words and their counts from the content, excluding stopwords and short words
 // Takes a content string, a map of stopwords, and a regex for splitting words
 func getWordCount(content string, stopwords map[string]struct{}, wordSplitter *regexp.Regexp) (TermCountIndex, error) {
+	return getTokenCount(tokenize(content, wordSplitter), defaultTokenFilterChain(stopwords, defaultMinWordLength))
+}
+
+// Returns a map of words and their counts from already tokenized content, counting only the words
+// the filter chain keeps
+func getTokenCount(tokens []Token, filters TokenFilterChain) (TermCountIndex, error) {
 	tci := make(TermCountIndex)
 
-	// get words
-	words := wordSplitter.Split(strings.ToLower(content), -1)
-	for _, word := range words {
-		// if word is not a space or digit and is longer than two and not a stopword
-		if word != "" && !unicode.IsDigit(rune(word[0])) && len(word) > 2 {
-			if _, ok := stopwords[word]; !ok {
-				// add word to tfi and increase count
-				tci[word]++
-			}
+	for _, token := range tokens {
+		if filters.Keep(token.Text) {
+			// add word to tfi and increase count
+			tci[token.Text]++
 		}
 	}
 	// handle no valid words case
 	if len(tci) == 0 {
-		return nil, errors.New("no valid words found in content")
+		return nil, errNoValidWords
 	}
 
 	return tci, nil
@@ -54,31 +60,49 @@ func getWordFrequency(content string, wordSplitter *regexp.Regexp, tci TermCount
 
 }
 
-// getKeywords takes a TermFrequencyIndex and returns the top N keywords based on which words are most frequent
-// returns the top N keywords in descending order of frequency
-func getKeywords(wordFrequency TermFrequencyIndex, topN int) []string {
-	// Sort the word frequency index by frequency
-	type kv struct {
-		Key   string
-		Value float64
-	}
-	var sorted []kv
+// Keyword is a ranked term along with its score and how many times it was counted
+// Snippets and Sentences are only filled in when extraction is asked for them
+// Concept is the id of the vocabulary concept the term was mapped onto, if any
+type Keyword struct {
+	Term      string     `json:"term"`
+	Score     float64    `json:"score"`
+	Count     int        `json:"count"`
+	Concept   string     `json:"concept,omitempty"`
+	Snippets  []Snippet  `json:"snippets,omitempty"`
+	Sentences []Sentence `json:"sentences,omitempty"`
+}
+
+// rankKeywords turns a TermFrequencyIndex into a slice of keywords in descending order of score
+// ties are broken alphabetically so the ranking is stable between runs, tci supplies the counts and may be nil
+func rankKeywords(wordFrequency TermFrequencyIndex, tci TermCountIndex) []Keyword {
+	ranked := make([]Keyword, 0, len(wordFrequency))
 
-	// Convert the map to a slice of key value pairs
-	for k, v := range wordFrequency {
-		sorted = append(sorted, kv{k, v})
+	// Convert the map to a slice of keywords
+	for term, score := range wordFrequency {
+		ranked = append(ranked, Keyword{Term: term, Score: score, Count: tci[term]})
 	}
 
-	// Sort by value (frequency)
-	sort.Slice(sorted, func(i, j int) bool {
-		return sorted[i].Value > sorted[j].Value
+	// Sort by score then term
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Term < ranked[j].Term
 	})
 
+	return ranked
+}
+
+// getKeywords takes a TermFrequencyIndex and returns the top N keywords based on which words are most frequent
+// returns the top N keywords in descending order of frequency
+func getKeywords(wordFrequency TermFrequencyIndex, topN int) []string {
+	ranked := rankKeywords(wordFrequency, nil)
+
 	keywords := make([]string, 0, topN)
 
 	// add the top N keywords
-	for i := 0; i < topN && i < len(sorted); i++ {
-		keywords = append(keywords, sorted[i].Key)
+	for i := 0; i < topN && i < len(ranked); i++ {
+		keywords = append(keywords, ranked[i].Term)
 	}
 
 	return keywords
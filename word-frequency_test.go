@@ -12,6 +12,7 @@ This file tests for:
 - content with a single word
 - handling punctuation in content
 - handling case sensitivity
+- ranking keywords with stable tie breaks
 
 */
 
@@ -228,3 +229,35 @@ func BenchmarkGetWordFrequency(b *testing.B) {
 		getWordFrequency(content, wordSplitter, tci)
 	}
 }
+
+func TestRankKeywords(t *testing.T) {
+	tfi := TermFrequencyIndex{
+		"banana": 0.25,
+		"apple":  0.5,
+		"cherry": 0.25,
+	}
+	tci := TermCountIndex{
+		"apple":  2,
+		"banana": 1,
+		"cherry": 1,
+	}
+
+	ranked := rankKeywords(tfi, tci)
+
+	// highest score first, ties broken alphabetically
+	expected := []string{"apple", "banana", "cherry"}
+	for i, term := range expected {
+		if ranked[i].Term != term {
+			t.Errorf("Expected '%s' at position %d, got '%s'", term, i, ranked[i].Term)
+		}
+	}
+	if ranked[0].Count != 2 {
+		t.Errorf("Expected count 2 for 'apple', got %d", ranked[0].Count)
+	}
+
+	// getKeywords should return the same order
+	keywords := getKeywords(tfi, 2)
+	if len(keywords) != 2 || keywords[0] != "apple" || keywords[1] != "banana" {
+		t.Errorf("Expected [apple banana], got %v", keywords)
+	}
+}